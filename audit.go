@@ -0,0 +1,165 @@
+package shellac
+
+import (
+	"encoding/json"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuditLog is a Hook that appends a JSON object describing each command, as
+// an AuditRecord, to a file once it exits.  Add it to Hooks to audit every
+// command, including those escalated by Sudo, wrapped by Wrap, run by RunAll,
+// or run remotely by ssh.Remote.  It's safe for concurrent use.
+type AuditLog struct {
+	NopHook
+	err      error
+	file     *os.File
+	maxSize  int64
+	mu       sync.Mutex
+	pathname string
+	size     int64
+	user     string
+}
+
+// NewAuditLog opens the named file for appending, creating it if necessary.
+// When appending would grow it beyond maxSize bytes, it's renamed with a .1
+// suffix, replacing any previous one, and a new file is started.  If maxSize
+// is 0, it's never rotated.
+func NewAuditLog(pathname string, maxSize int64) (*AuditLog, error) {
+	l := &AuditLog{
+		maxSize:  maxSize,
+		pathname: pathname,
+		user:     strconv.Itoa(os.Getuid()),
+	}
+	if u, err := user.Current(); nil == err {
+		l.user = u.Username
+	}
+	if err := l.open(); nil != err {
+		return nil, err
+	}
+	return l, nil
+}
+
+// AfterExit appends an AuditRecord describing the command.  Errors are kept
+// and returned by Close.
+func (l *AuditLog) AfterExit(cmd *Cmd, r *Result) {
+	if err := l.Append(l.record(cmd, r)); nil != err {
+		l.mu.Lock()
+		if nil == l.err {
+			l.err = err
+		}
+		l.mu.Unlock()
+	}
+}
+
+// Append appends rec to the file as a single line, rotating it first if it's
+// too big.
+func (l *AuditLog) Append(rec *AuditRecord) error {
+	p, err := json.Marshal(rec)
+	if nil != err {
+		return err
+	}
+	p = append(p, '\n')
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if 0 < l.maxSize && 0 < l.size && l.size+int64(len(p)) > l.maxSize {
+		if err := l.rotate(); nil != err {
+			return err
+		}
+	}
+	n, err := l.file.Write(p)
+	l.size += int64(n)
+	return err
+}
+
+// Close closes the file and returns the first error from closing it or from
+// appending records in AfterExit.
+func (l *AuditLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.file.Close(); nil != err && nil == l.err {
+		l.err = err
+	}
+	return l.err
+}
+
+// open opens the file for appending and notes its size.
+func (l *AuditLog) open() error {
+	f, err := os.OpenFile(
+		l.pathname,
+		os.O_APPEND|os.O_CREATE|os.O_WRONLY,
+		0600,
+	)
+	if nil != err {
+		return err
+	}
+	fi, err := f.Stat()
+	if nil != err {
+		f.Close()
+		return err
+	}
+	l.file, l.size = f, fi.Size()
+	return nil
+}
+
+// record describes the command as it ran.
+func (l *AuditLog) record(cmd *Cmd, r *Result) *AuditRecord {
+	rec := &AuditRecord{
+		Time:     cmd.started,
+		User:     l.user,
+		Args:     cmd.RedactedArgs(),
+		Dir:      cmd.Dir,
+		Duration: r.Duration.Round(time.Millisecond).String(),
+		ExitCode: r.ExitCode,
+	}
+	if 0 != len(cmd.sudo) {
+		rec.Sudo = cmd.redactAll(cmd.sudo)
+	}
+	for _, wrapper := range cmd.wrappers {
+		rec.Wrappers = append(rec.Wrappers, cmd.redactAll(wrapper))
+	}
+	if "" == rec.Dir {
+		rec.Dir, _ = os.Getwd()
+	}
+	for _, kv := range cmd.ChangedEnv() {
+		k, _, _ := strings.Cut(kv, "=")
+		rec.Env = append(rec.Env, k)
+	}
+	if nil != cmd.process {
+		rec.PID = cmd.process.Pid()
+	}
+	if nil != r.Err {
+		rec.Error = cmd.Redact(r.Err.Error())
+	}
+	return rec
+}
+
+// rotate renames the file with a .1 suffix and opens a new one.
+func (l *AuditLog) rotate() error {
+	if err := l.file.Close(); nil != err {
+		return err
+	}
+	if err := os.Rename(l.pathname, l.pathname+".1"); nil != err {
+		return err
+	}
+	return l.open()
+}
+
+// AuditRecord describes one command in an AuditLog.  Secrets are redacted.
+type AuditRecord struct {
+	Time     time.Time  `json:"time"`               // when it started
+	User     string     `json:"user"`               // who ran it
+	Sudo     []string   `json:"sudo,omitempty"`     // escalation, if any
+	Wrappers [][]string `json:"wrappers,omitempty"` // wrappers, outermost first
+	Args     []string   `json:"args"`               // the full command line
+	Dir      string     `json:"dir"`                // working directory
+	Env      []string   `json:"env,omitempty"`      // names of variables changed
+	Duration string     `json:"duration"`
+	ExitCode int        `json:"exit_code"`
+	PID      int        `json:"pid,omitempty"`
+	Error    string     `json:"error,omitempty"`
+}
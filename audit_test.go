@@ -0,0 +1,118 @@
+package shellac
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/rcrowley/go-shellac/coreutils"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestAuditLog(t *testing.T) {
+	pathname := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := NewAuditLog(pathname, 0)
+	if nil != err {
+		t.Fatal(err)
+	}
+	cmd := toCmd(exec.Command("sh", "-c", "exit 3", "hunter2"))
+	cmd.Secret("hunter2")
+	cmd.Env = []string{"FOO=bar"}
+	if err := cmd.Wrap(coreutils.Nice{Adjustment: NewInt(10)}); nil != err {
+		t.Fatal(err)
+	}
+	cmd.Hooks = []Hook{l}
+	cmd.Run()
+	if err := l.Close(); nil != err {
+		t.Fatal(err)
+	}
+	recs := testAuditRecords(t, pathname)
+	if 1 != len(recs) {
+		t.Fatal(recs)
+	}
+	rec := recs[0]
+	testArgs(
+		t,
+		[]string{"nice", "-n", "10", "sh", "-c", "exit 3", Redacted},
+		rec.Args,
+	)
+	if 1 != len(rec.Wrappers) || "nice" != rec.Wrappers[0][0] {
+		t.Fatal(rec.Wrappers)
+	}
+	if 3 != rec.ExitCode || 0 == rec.PID || "" == rec.Error {
+		t.Fatal(rec)
+	}
+	if "" == rec.User || "" == rec.Dir || rec.Time.IsZero() {
+		t.Fatal(rec)
+	}
+	testArgs(t, []string{"FOO"}, rec.Env)
+}
+
+func TestAuditLogSecretEnv(t *testing.T) {
+	pathname := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := NewAuditLog(pathname, 0)
+	if nil != err {
+		t.Fatal(err)
+	}
+	cmd := toCmd(exec.Command("true"))
+	cmd.SecretEnv("SHELLAC_TOKEN", "hunter2")
+	cmd.Hooks = []Hook{l}
+	if err := cmd.Run(); nil != err {
+		t.Fatal(err)
+	}
+	if err := l.Close(); nil != err {
+		t.Fatal(err)
+	}
+	recs := testAuditRecords(t, pathname)
+	if 1 != len(recs) {
+		t.Fatal(recs)
+	}
+	testArgs(t, []string{"SHELLAC_TOKEN"}, recs[0].Env)
+}
+
+func TestAuditLogRotate(t *testing.T) {
+	pathname := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := NewAuditLog(pathname, 1)
+	if nil != err {
+		t.Fatal(err)
+	}
+	for _, arg := range []string{"a", "b"} {
+		cmd := toCmd(exec.Command("echo", arg))
+		cmd.Stdout = nil
+		cmd.Hooks = []Hook{l}
+		if err := cmd.Run(); nil != err {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Close(); nil != err {
+		t.Fatal(err)
+	}
+	for pathname, arg := range map[string]string{
+		pathname + ".1": "a",
+		pathname:        "b",
+	} {
+		recs := testAuditRecords(t, pathname)
+		if 1 != len(recs) || arg != recs[0].Args[1] {
+			t.Fatal(pathname, recs)
+		}
+	}
+}
+
+func testAuditRecords(t *testing.T, pathname string) []*AuditRecord {
+	f, err := os.Open(pathname)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var recs []*AuditRecord
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		rec := &AuditRecord{}
+		if err := json.Unmarshal(s.Bytes(), rec); nil != err {
+			t.Fatal(err)
+		}
+		recs = append(recs, rec)
+	}
+	return recs
+}
@@ -0,0 +1,155 @@
+package shellac
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrSkipped is the error in the BatchResult of each command that RunAll
+// didn't start because the context was done or, with FailFast, because
+// another command failed.
+var ErrSkipped = errors.New("shellac: command skipped")
+
+// BatchError describes a RunAll in which at least one command failed or was
+// skipped.
+type BatchError struct {
+	Results []*BatchResult // every result, in order, including successes
+}
+
+// Error returns how many commands failed and were skipped and the error from
+// the first that failed.
+func (e *BatchError) Error() string {
+	var failed, skipped int
+	var first error
+	for _, r := range e.Results {
+		if ErrSkipped == r.Err {
+			skipped++
+		} else if nil != r.Err {
+			failed++
+			if nil == first {
+				first = r.Err
+			}
+		}
+	}
+	s := fmt.Sprintf(
+		"shellac: %d of %d commands failed",
+		failed,
+		len(e.Results),
+	)
+	if 0 < skipped {
+		s = fmt.Sprintf("%s, %d skipped", s, skipped)
+	}
+	if nil != first {
+		s = fmt.Sprintf("%s: %v", s, first)
+	}
+	return s
+}
+
+// Unwrap returns every non-nil error, in order, so errors.Is and errors.As
+// look at all of them.
+func (e *BatchError) Unwrap() []error {
+	var errs []error
+	for _, r := range e.Results {
+		if nil != r.Err {
+			errs = append(errs, r.Err)
+		}
+	}
+	return errs
+}
+
+// BatchResult describes one command run by RunAll.
+type BatchResult struct {
+	Cmd            *Cmd
+	Err            error         // as from Run or ErrSkipped
+	Duration       time.Duration // how long the command ran
+	Stdout, Stderr []byte        // captured standard output and error
+}
+
+// RunAllOptions configures RunAll.  The zero value runs every command at once
+// and runs every command even if some fail.
+type RunAllOptions struct {
+	Limit    int  // the most commands to run at once or 0 for no limit
+	FailFast bool // skip the rest and kill the others after the first failure
+}
+
+// RunAll runs each command described by the given interface values, which may
+// be anything Run accepts, concurrently.  Commands that are still running when
+// ctx is done are killed.  Standard output and error are captured in each
+// BatchResult alongside any channels, callbacks, or tees already connected,
+// replacing os.Stdout and os.Stderr so they don't interleave.  Commands logged
+// by a TextLogger are prefixed with their position.  Skipped commands exit as
+// though they failed to start, closing channels and calling Hooks.  The
+// results are in the same order as cmds and the error, if any, is a
+// *BatchError.
+func RunAll(
+	ctx context.Context,
+	cmds []interface{},
+	opts RunAllOptions,
+) ([]*BatchResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	limit := opts.Limit
+	if limit < 1 || limit > len(cmds) {
+		limit = len(cmds)
+	}
+	sem := make(chan struct{}, limit)
+	results := make([]*BatchResult, len(cmds))
+	var wg sync.WaitGroup
+	for i, v := range cmds {
+		r := &BatchResult{Cmd: toCmd(v)}
+		results[i] = r
+		if l, ok := r.Cmd.logger().(*TextLogger); ok {
+			prefixed := *l
+			prefixed.Prefix = fmt.Sprintf("[%d/%d] ", i+1, len(cmds))
+			r.Cmd.Logger = &prefixed
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if nil != ctx.Err() {
+			r.skip()
+			continue // sem may be full but nothing else will wait on it
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			r.run(ctx)
+			if nil != r.Err && opts.FailFast {
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+	for _, r := range results {
+		if nil != r.Err {
+			return results, &BatchError{Results: results}
+		}
+	}
+	return results, nil
+}
+
+// run runs the command, captures its output, and records how it went.
+func (r *BatchResult) run(ctx context.Context) {
+	var stdout, stderr bytes.Buffer
+	r.Cmd.TeeStdout(&stdout)
+	r.Cmd.TeeStderr(&stderr)
+	start := time.Now()
+	r.Err = r.Cmd.runContext(ctx)
+	r.Duration = time.Since(start)
+	r.Stdout, r.Stderr = stdout.Bytes(), stderr.Bytes()
+}
+
+// skip exits the command as though it failed to start, since it won't, and
+// records that it was skipped.
+func (r *BatchResult) skip() {
+	r.Cmd.begin()
+	r.Cmd.beforeStart() // only so AfterExit Hooks are called; it's skipped anyway
+	r.Cmd.finish(ErrSkipped)
+	r.Err = ErrSkipped
+}
@@ -0,0 +1,119 @@
+package shellac
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRunAll(t *testing.T) {
+	var b syncBuffer
+	defer func(l Logger) { DefaultLogger = l }(DefaultLogger)
+	DefaultLogger = &TextLogger{W: &b}
+	results, err := RunAll(context.Background(), []interface{}{
+		exec.Command("echo", "a"),
+		exec.Command("sh", "-c", "echo b >&2; exit 3"),
+		exec.Command("echo", "c"),
+	}, RunAllOptions{Limit: 2})
+	var e *BatchError
+	if !errors.As(err, &e) || 3 != len(e.Results) {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(err.Error(), "shellac: 1 of 3 commands failed: ") {
+		t.Fatal(err)
+	}
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || 3 != exitErr.ExitCode {
+		t.Fatal(err)
+	}
+	if nil != results[0].Err || "a\n" != string(results[0].Stdout) {
+		t.Fatal(results[0])
+	}
+	if "b\n" != string(results[1].Stderr) {
+		t.Fatal(results[1])
+	}
+	if nil != results[2].Err || "c\n" != string(results[2].Stdout) {
+		t.Fatal(results[2])
+	}
+	for _, s := range []string{"[1/3] echo a\n", "[3/3] echo c\n"} {
+		if !strings.Contains(b.String(), s) {
+			t.Fatal(b.String())
+		}
+	}
+}
+
+func TestRunAllFailFast(t *testing.T) {
+	start := time.Now()
+	results, err := RunAll(context.Background(), []interface{}{
+		exec.Command("false"),
+		exec.Command("sleep", "10"),
+		exec.Command("true"),
+	}, RunAllOptions{Limit: 2, FailFast: true})
+	if nil == err {
+		t.Fatal(results)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatal(time.Since(start))
+	}
+	if nil == results[1].Err || ErrSkipped != results[2].Err {
+		t.Fatal(results[1], results[2])
+	}
+	if !strings.Contains(err.Error(), ", 1 skipped: ") {
+		t.Fatal(err)
+	}
+}
+
+func TestRunAllSkipped(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	h := &testHook{}
+	cmd := Command(test{})
+	cmd.Hooks = []Hook{h}
+	ch := make(chan string)
+	cmd.ChannelStdout(ch)
+	results, err := RunAll(ctx, []interface{}{cmd}, RunAllOptions{})
+	if nil == err || ErrSkipped != results[0].Err {
+		t.Fatal(err)
+	}
+	if _, ok := <-ch; ok {
+		t.Fatal("channel still open")
+	}
+	select {
+	case <-cmd.Done():
+	default:
+		t.Fatal("not done")
+	}
+	if nil == h.result || !errors.Is(h.result.Err, ErrSkipped) {
+		t.Fatal(h.result)
+	}
+}
+
+func TestRunAllSuccess(t *testing.T) {
+	results, err := RunAll(context.Background(), []interface{}{
+		exec.Command("true"),
+		exec.Command("true"),
+	}, RunAllOptions{})
+	if nil != err {
+		t.Fatal(err)
+	}
+	if 2 != len(results) || 0 == results[1].Duration {
+		t.Fatal(results)
+	}
+}
+
+// syncBuffer is a bytes.Buffer that's safe to write concurrently.
+type syncBuffer struct {
+	bytes.Buffer
+	mu sync.Mutex
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.Buffer.Write(p)
+}
@@ -2,25 +2,93 @@ package shellac
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"io"
+	"sync"
+	"sync/atomic"
 )
 
+// ByteChanReader is a bridge between the receive side of a channel of raw
+// chunks of bytes and io.Reader.
+type ByteChanReader struct {
+	buffer bytes.Buffer
+	ch     <-chan []byte
+}
+
+// NewByteChanReader constructs a ByteChanReader that reads from ch.
+func NewByteChanReader(ch <-chan []byte) *ByteChanReader {
+	return &ByteChanReader{
+		buffer: bytes.Buffer{},
+		ch:     ch,
+	}
+}
+
+// Read reads chunks from a channel and returns them to callers as-is.  It
+// eventually returns io.EOF after the channel is closed and the buffer is
+// emptied.
+func (r *ByteChanReader) Read(p []byte) (int, error) {
+	for 0 == r.buffer.Len() {
+		b, ok := <-r.ch
+		if !ok {
+			return 0, io.EOF
+		}
+		r.buffer.Write(b)
+	}
+	return r.buffer.Read(p)
+}
+
+// ByteChanWriter is a bridge between the sending side of a channel of raw
+// chunks of bytes and io.Writer.  It's safe for binary output.
+type ByteChanWriter struct {
+	ch chan<- []byte
+}
+
+// NewByteChanWriter constructs a ByteChanWriter that sends to ch.
+func NewByteChanWriter(ch chan<- []byte) *ByteChanWriter {
+	return &ByteChanWriter{ch: ch}
+}
+
+// Close closes the channel.
+func (w *ByteChanWriter) Close() error {
+	close(w.ch)
+	return nil
+}
+
+// Write sends a copy of each chunk to a channel as it's passed by callers.
+func (w *ByteChanWriter) Write(p []byte) (int, error) {
+	if 0 < len(p) {
+		w.ch <- append([]byte{}, p...)
+	}
+	return len(p), nil
+}
+
 // ChanReader is a bridge between the receive side of a channel and io.Reader.
 type ChanReader struct {
 	buffer bytes.Buffer
 	ch     <-chan string
+	delim  byte
 }
 
-// NewChanReader constructs a ChanReader that reads from ch.
+// NewChanReader constructs a ChanReader that reads newline-delimited lines
+// from ch.
 func NewChanReader(ch <-chan string) *ChanReader {
+	return NewChanReaderDelim(ch, '\n')
+}
+
+// NewChanReaderDelim constructs a ChanReader that reads lines from ch and
+// delimits them with delim, as in NewChanReaderDelim(ch, 0) for xargs -0.
+func NewChanReaderDelim(ch <-chan string, delim byte) *ChanReader {
 	return &ChanReader{
 		buffer: bytes.Buffer{},
 		ch:     ch,
+		delim:  delim,
 	}
 }
 
-// Read reads lines from a channel and returns them to callers.  It eventually
-// returns io.EOF after the channel is closed and the buffer is emptied.
+// Read reads lines from a channel and returns them to callers, each followed
+// by the delimiter.  It eventually returns io.EOF after the channel is closed
+// and the buffer is emptied.
 func (r *ChanReader) Read(p []byte) (int, error) {
 	n, err := r.buffer.Read(p)
 	if io.EOF == err {
@@ -31,8 +99,8 @@ func (r *ChanReader) Read(p []byte) (int, error) {
 		if n, err := r.buffer.Write([]byte(s)); nil != err || len(s) != n {
 			return 0, err
 		}
-		if 0 == len(s) || '\n' != s[len(s)-1] {
-			if _, err := r.buffer.Write([]byte{'\n'}); nil != err {
+		if 0 == len(s) || r.delim != s[len(s)-1] {
+			if err := r.buffer.WriteByte(r.delim); nil != err {
 				return 0, err
 			}
 		}
@@ -41,45 +109,263 @@ func (r *ChanReader) Read(p []byte) (int, error) {
 	return n, err
 }
 
+// ChanPolicy is an enumeration of what a ChanWriter does when its channel's
+// consumer doesn't keep up.
+type ChanPolicy int
+
+var (
+	ChanBlock      ChanPolicy = 0 // block until the consumer receives
+	ChanBuffer     ChanPolicy = 1 // buffer up to a bound, then block
+	ChanDropOldest ChanPolicy = 2 // buffer up to a bound, then drop the oldest
+	ChanDropNewest ChanPolicy = 3 // buffer up to a bound, then drop the newest
+	ChanFail       ChanPolicy = 4 // buffer up to a bound, then fail
+)
+
+// ErrChanFull is returned by ChanWriter.Write when its policy is ChanFail and
+// its buffer is full.  Every Write after that fails, too, and the lines are
+// dropped.
+var ErrChanFull = errors.New("shellac: channel buffer full")
+
 // ChanWriter is a bridge between the sending side of a channel and io.Writer.
+//
+// By default, Write blocks until each line is received, which stalls the
+// command if the consumer stalls.  SetPolicy configures a buffer and what to
+// do when it's full and SetContext stops sending if the consumer goes away.
 type ChanWriter struct {
-	buffer bytes.Buffer
-	ch     chan<- string
+	bound   int
+	buffer  bytes.Buffer
+	ch      chan<- string
+	ctx     context.Context
+	delim   byte
+	dropped uint64
+	err     error
+	policy  ChanPolicy
+	queue   *chanQueue
 }
 
-// NewChanWriter constructs a ChanWriter that sends to ch.
+// NewChanWriter constructs a ChanWriter that sends newline-delimited lines to
+// ch.
 func NewChanWriter(ch chan<- string) *ChanWriter {
+	return NewChanWriterDelim(ch, '\n')
+}
+
+// NewChanWriterDelim constructs a ChanWriter that sends lines delimited by
+// delim to ch, as in NewChanWriterDelim(ch, 0) for find -print0.
+func NewChanWriterDelim(ch chan<- string, delim byte) *ChanWriter {
 	return &ChanWriter{
 		buffer: bytes.Buffer{},
 		ch:     ch,
+		ctx:    context.Background(),
+		delim:  delim,
 	}
 }
 
-// Close drains the buffer and closes the channel.
+// Close drains the buffer and closes the channel.  Under ChanDropOldest,
+// ChanDropNewest, and ChanFail, it doesn't wait for a consumer that isn't
+// receiving; the rest of the buffer is sent and the channel closed in the
+// background or, once the context is done, they're dropped.  Without a
+// context from SetContext, a goroutine leaks for as long as the consumer
+// doesn't receive, forever if it never does.  Under every policy but
+// ChanBlock, a ChanWriter that's written to and never closed leaks one, too.
 func (w *ChanWriter) Close() error {
 	for {
-		s, err := w.buffer.ReadString('\n')
+		s, err := w.buffer.ReadString(w.delim)
 		if nil != err {
 			if "" != s {
-				w.ch <- s
+				w.send(s)
 			}
 			break
 		}
-		w.ch <- s[:len(s)-1]
+		w.send(s[:len(s)-1])
+	}
+	if nil != w.queue {
+		w.queue.close()
+	} else {
+		close(w.ch)
 	}
-	close(w.ch)
 	return nil
 }
 
-// Write sends lines to a channel as they are passed by callers.
+// Dropped returns the number of lines dropped because the buffer was full or
+// the context was canceled.
+func (w *ChanWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+// SetContext causes sends to give up, and Write to fail, once ctx is done.
+// It must be called before the first Write.
+func (w *ChanWriter) SetContext(ctx context.Context) {
+	w.ctx = ctx
+}
+
+// SetPolicy configures what to do when the consumer doesn't keep up.  Every
+// policy but ChanBlock buffers up to n lines in between Write and the channel,
+// sent by a goroutine started by the first Write.  It must be called before
+// the first Write.
+func (w *ChanWriter) SetPolicy(policy ChanPolicy, n int) {
+	w.policy, w.bound = policy, n
+}
+
+// Write sends lines to a channel, without their delimiters, as they are passed
+// by callers.
 func (w *ChanWriter) Write(p []byte) (int, error) {
+	if nil != w.err {
+		return 0, w.err
+	}
 	n, err := w.buffer.Write(p)
-	for i := bytes.Count(p, []byte{'\n'}); i > 0; i-- {
-		s, err := w.buffer.ReadString('\n')
+	for i := bytes.Count(p, []byte{w.delim}); i > 0; i-- {
+		s, err := w.buffer.ReadString(w.delim)
 		if nil != err {
 			break
 		}
-		w.ch <- s[:len(s)-1]
+		if err := w.send(s[:len(s)-1]); nil != err {
+			return n, err
+		}
 	}
 	return n, err
 }
+
+// send sends s to the channel according to the policy.
+func (w *ChanWriter) send(s string) error {
+	if nil != w.err {
+		atomic.AddUint64(&w.dropped, 1)
+		return w.err
+	}
+	if ChanBlock != w.policy {
+		if nil == w.queue {
+			w.queue = newChanQueue(w, w.bound)
+		}
+		w.err = w.queue.push(s)
+		return w.err
+	}
+	select {
+	case w.ch <- s:
+		return nil
+	case <-w.ctx.Done():
+		atomic.AddUint64(&w.dropped, 1)
+		w.err = w.ctx.Err()
+		return w.err
+	}
+}
+
+// chanQueue is the bounded buffer in between a ChanWriter and its channel.
+type chanQueue struct {
+	blocked bool // sending to a consumer that isn't receiving
+	cond    *sync.Cond
+	closed  bool
+	done    bool
+	err     error
+	lines   []string
+	mu      sync.Mutex
+	n       int
+	w       *ChanWriter
+}
+
+// newChanQueue constructs a chanQueue that buffers up to n lines and starts
+// sending them to w's channel.
+func newChanQueue(w *ChanWriter, n int) *chanQueue {
+	if n < 1 {
+		n = 1
+	}
+	q := &chanQueue{n: n, w: w}
+	q.cond = sync.NewCond(&q.mu)
+	go q.pump()
+	return q
+}
+
+// close waits for the buffer to drain into the channel and the channel to be
+// closed or, unless the policy is ChanBuffer, for the consumer to stop
+// receiving.
+func (q *chanQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.cond.Broadcast()
+	for !q.done && (ChanBuffer == q.w.policy || !q.blocked) {
+		q.cond.Wait()
+	}
+}
+
+// pump sends lines from the buffer to the channel until the queue is closed
+// and empty, dropping them once the context is done, and then closes the
+// channel.
+func (q *chanQueue) pump() {
+	for {
+		q.mu.Lock()
+		for 0 == len(q.lines) && !q.closed {
+			q.cond.Wait()
+		}
+		if 0 == len(q.lines) {
+			close(q.w.ch)
+			q.done = true
+			q.cond.Broadcast()
+			q.mu.Unlock()
+			return
+		}
+		s := q.lines[0]
+		q.lines = q.lines[1:]
+		q.cond.Broadcast()
+		q.mu.Unlock()
+		if err := q.send(s); nil != err {
+			q.mu.Lock()
+			q.err = err
+			atomic.AddUint64(&q.w.dropped, uint64(1+len(q.lines)))
+			q.lines = nil
+			q.cond.Broadcast()
+			q.mu.Unlock()
+		}
+	}
+}
+
+// push adds s to the buffer, handling a full buffer according to the policy.
+func (q *chanQueue) push(s string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for nil == q.err && len(q.lines) >= q.n {
+		switch q.w.policy {
+		case ChanDropOldest:
+			q.lines = q.lines[1:]
+			atomic.AddUint64(&q.w.dropped, 1)
+		case ChanDropNewest:
+			atomic.AddUint64(&q.w.dropped, 1)
+			return nil
+		case ChanFail:
+			atomic.AddUint64(&q.w.dropped, 1)
+			return ErrChanFull
+		default:
+			q.cond.Wait()
+		}
+	}
+	if nil != q.err {
+		atomic.AddUint64(&q.w.dropped, 1)
+		return q.err
+	}
+	q.lines = append(q.lines, s)
+	q.cond.Broadcast()
+	return nil
+}
+
+// send sends s to the channel, noting when the consumer isn't receiving, and
+// returns an error if the context is done first.
+func (q *chanQueue) send(s string) error {
+	select {
+	case q.w.ch <- s:
+		return nil
+	default:
+	}
+	q.mu.Lock()
+	q.blocked = true
+	q.cond.Broadcast()
+	q.mu.Unlock()
+	defer func() {
+		q.mu.Lock()
+		q.blocked = false
+		q.mu.Unlock()
+	}()
+	select {
+	case q.w.ch <- s:
+		return nil
+	case <-q.w.ctx.Done():
+		return q.w.ctx.Err()
+	}
+}
@@ -1,8 +1,12 @@
 package shellac
 
 import (
+	"context"
 	"io"
+	"os/exec"
+	"runtime"
 	"testing"
+	"time"
 )
 
 func TestChanReader(t *testing.T) {
@@ -127,3 +131,223 @@ func TestChanWriterUnderflow(t *testing.T) {
 		t.Fatal(ok)
 	}
 }
+
+func TestByteChanReader(t *testing.T) {
+	ch := make(chan []byte)
+	r := NewByteChanReader(ch)
+	go func() {
+		ch <- []byte{0, 1}
+		ch <- []byte{}
+		ch <- []byte{'\n'}
+		close(ch)
+	}()
+	p, err := io.ReadAll(r)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if "\x00\x01\n" != string(p) {
+		t.Fatal(p)
+	}
+}
+
+func TestByteChanWriter(t *testing.T) {
+	ch := make(chan []byte, 2)
+	w := NewByteChanWriter(ch)
+	p := []byte("hi\x00")
+	if _, err := w.Write(p); nil != err {
+		t.Fatal(err)
+	}
+	p[0] = 'H'
+	w.Close()
+	if b := <-ch; "hi\x00" != string(b) {
+		t.Fatal(b)
+	}
+	if _, ok := <-ch; ok {
+		t.Fatal(ok)
+	}
+}
+
+func TestChanReaderDelim(t *testing.T) {
+	ch := make(chan string)
+	r := NewChanReaderDelim(ch, 0)
+	go func() {
+		ch <- "foo\nbar"
+		ch <- "baz\x00"
+		close(ch)
+	}()
+	p, err := io.ReadAll(r)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if "foo\nbar\x00baz\x00" != string(p) {
+		t.Fatal(p)
+	}
+}
+
+func TestChanWriterDelim(t *testing.T) {
+	ch := make(chan string, 3)
+	w := NewChanWriterDelim(ch, 0)
+	if _, err := w.Write([]byte("foo\nbar\x00ba")); nil != err {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("z\x00qu")); nil != err {
+		t.Fatal(err)
+	}
+	w.Close()
+	for _, s := range []string{"foo\nbar", "baz", "qu"} {
+		if actual := <-ch; s != actual {
+			t.Fatal(actual)
+		}
+	}
+}
+
+func TestChanWriterContext(t *testing.T) {
+	ch := make(chan string)
+	w := NewChanWriter(ch)
+	ctx, cancel := context.WithCancel(context.Background())
+	w.SetContext(ctx)
+	cancel()
+	if _, err := w.Write([]byte("hi\nhi\n")); context.Canceled != err {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("hi\n")); context.Canceled != err {
+		t.Fatal(err)
+	}
+	w.Close()
+	if 2 != w.Dropped() {
+		t.Fatal(w.Dropped())
+	}
+	if _, ok := <-ch; ok {
+		t.Fatal(ok)
+	}
+}
+
+func TestChanWriterDropNewest(t *testing.T) {
+	testChanWriterPolicy(t, ChanDropNewest, []string{"1", "2"}, 2, nil)
+}
+
+func TestChanWriterDropOldest(t *testing.T) {
+	testChanWriterPolicy(t, ChanDropOldest, []string{"3", "4"}, 2, nil)
+}
+
+func TestChanWriterFail(t *testing.T) {
+	testChanWriterPolicy(t, ChanFail, []string{"1", "2"}, 1, ErrChanFull)
+}
+
+func TestChanWriterRun(t *testing.T) {
+	ch := make(chan string)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cmd := toCmd(exec.Command("yes"))
+	w := NewChanWriter(ch)
+	w.SetContext(ctx)
+	cmd.Stdout = w
+	done := make(chan error)
+	go func() { done <- cmd.Run() }()
+	<-ch
+	cancel()
+	if err := <-done; nil == err {
+		t.Fatal(err)
+	}
+	for range ch {
+	}
+}
+
+func TestChanWriterStalled(t *testing.T) {
+	for _, policy := range []ChanPolicy{
+		ChanDropOldest,
+		ChanDropNewest,
+		ChanFail,
+	} {
+		ch := make(chan string)
+		cmd := toCmd(exec.Command("seq", "100000"))
+		w := NewChanWriter(ch)
+		w.SetPolicy(policy, 2)
+		cmd.Stdout = w
+		done := make(chan error, 1)
+		go func() { done <- cmd.Run() }()
+		<-ch
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal(policy)
+		}
+		for range ch {
+		}
+		if 0 == w.Dropped() {
+			t.Fatal(policy, w.Dropped())
+		}
+	}
+}
+
+func TestChanWriterSetPolicy(t *testing.T) {
+	ch := make(chan string, 1)
+	w := NewChanWriter(ch)
+	w.SetPolicy(ChanDropOldest, 2)
+	w.SetPolicy(ChanBuffer, 1)
+	if nil != w.queue {
+		t.Fatal(w.queue)
+	}
+	if _, err := w.Write([]byte("0\n")); nil != err {
+		t.Fatal(err)
+	}
+	if nil == w.queue || 1 != w.queue.n {
+		t.Fatal(w.queue)
+	}
+	w.Close()
+	if s := <-ch; "0" != s {
+		t.Fatal(s)
+	}
+	if _, ok := <-ch; ok {
+		t.Fatal("channel still open")
+	}
+}
+
+// testChanWriterPolicy writes "0" and waits for it to be taken from the buffer
+// then writes up to four more lines to a buffer of two and expects the given
+// lines after "0" to be received and the rest to be dropped.
+func testChanWriterPolicy(
+	t *testing.T,
+	policy ChanPolicy,
+	expected []string,
+	dropped uint64,
+	expectedErr error,
+) {
+	ch := make(chan string)
+	w := NewChanWriter(ch)
+	w.SetPolicy(policy, 2)
+	if _, err := w.Write([]byte("0\n")); nil != err {
+		t.Fatal(err)
+	}
+	for {
+		w.queue.mu.Lock()
+		n := len(w.queue.lines)
+		w.queue.mu.Unlock()
+		if 0 == n {
+			break
+		}
+		runtime.Gosched()
+	}
+	var err error
+	for _, s := range []string{"1\n", "2\n", "3\n", "4\n"} {
+		if _, err = w.Write([]byte(s)); nil != err {
+			break
+		}
+	}
+	if expectedErr != err {
+		t.Fatal(err)
+	}
+	done := make(chan []string)
+	go func() {
+		actual := []string{}
+		for s := range ch {
+			actual = append(actual, s)
+		}
+		done <- actual
+	}()
+	w.Close()
+	testArgs(t, append([]string{"0"}, expected...), <-done)
+	if dropped != w.Dropped() {
+		t.Fatal(w.Dropped())
+	}
+}
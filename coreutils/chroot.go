@@ -0,0 +1,17 @@
+package coreutils
+
+// chroot(8)
+type Chroot struct {
+
+	// --groups=<groups>
+	Groups string `flag:"--groups" sep:"="`
+
+	// --skip-chdir
+	SkipChdir bool `flag:"--skip-chdir"`
+
+	// --userspec=<user>:<group>
+	UserSpec string `flag:"--userspec" sep:"="`
+
+	// <newroot>
+	NewRoot string `pos:"last"`
+}
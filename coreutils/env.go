@@ -0,0 +1,17 @@
+package coreutils
+
+// env(1)
+type Env struct {
+
+	// -C <dir>
+	Chdir string `flag:"-C"`
+
+	// -i
+	IgnoreEnvironment bool `flag:"-i"`
+
+	// -u <name>
+	Unset string `flag:"-u"`
+
+	// <name>=<value> ...
+	Vars []string `pos:"last"`
+}
@@ -6,6 +6,9 @@ import "fmt"
 //
 // There is no support for the complex logical expressions that are possible
 // with find(1).  If you need to execute such commands, use the exec package.
+//
+// Flags are as in GNU findutils.  Dialect tags note those that BSD and BusyBox
+// find(1) spell differently or lack.
 type Find struct {
 
 	// The three basic modes of operation on symbolic links.  The last of these
@@ -19,22 +22,22 @@ type Find struct {
 	FollowInitialSymlinks bool `flag:"-H" pos:"first"`
 
 	// -D <debugoptions>
-	DebugOptions string `flag:"-D" pos:"first"`
+	DebugOptions string `flag:"-D" pos:"first" since:"4.3" dialect:"bsd busybox"`
 
 	// -O<level>
-	Optimization int `flag:"-O" pos:"first" sep:"-"`
+	Optimization int `flag:"-O" pos:"first" sep:"-" dialect:"bsd busybox"`
 
 	// The list of directories from which find(1) will begin its traversal.
 	Dirnames []string `pos:"first"`
 
 	// -daystart
-	DayStart bool `flag:"-daystart"`
+	DayStart bool `flag:"-daystart" dialect:"bsd busybox"`
 
 	// -depth
 	DepthFirst bool `flag:"-depth"`
 
 	// -ignore_readdir_race and -noignore_readdir_race
-	IgnoreReaddirRace bool `flag:"-ignore_readdir_race"`
+	IgnoreReaddirRace bool `flag:"-ignore_readdir_race" dialect:"bsd busybox"`
 	//NoIgnoreReaddirRace bool `flag:"-noignore_readdir_race"`
 
 	// -maxdepth <levels>
@@ -44,13 +47,13 @@ type Find struct {
 	MinDepth *int `flag:"-mindepth"`
 
 	// -noleaf
-	NoLeaf bool `flag:"-noleaf"`
+	NoLeaf bool `flag:"-noleaf" dialect:"bsd busybox"`
 
 	// -regextype <type>
-	RegexType string `flag:"-regextype"`
+	RegexType string `flag:"-regextype" dialect:"bsd busybox"`
 
 	// -warn and -nowarn
-	Warn bool `flag:"-warn"`
+	Warn bool `flag:"-warn" dialect:"bsd busybox"`
 	// NoWarn bool `flag:"-nowarn"`
 
 	// -xdev (formerly known as -mount)
@@ -60,7 +63,7 @@ type Find struct {
 	AccessedMinutesAgo *FindN `flag:"-amin"`
 
 	// -anewer <file>
-	AccessedSinceFile string `flag:"-anewer"`
+	AccessedSinceFile string `flag:"-anewer" dialect:"busybox"`
 
 	// -atime <n>
 	AccessedDaysAgo *FindN `flag:"-atime"`
@@ -69,7 +72,7 @@ type Find struct {
 	ChangedMinutesAgo *FindN `flag:"-cmin"`
 
 	// -cnewer <file>
-	ChangedSinceFile string `flag:"-cnewer"`
+	ChangedSinceFile string `flag:"-cnewer" dialect:"busybox"`
 
 	// -ctime <n>
 	ChangedDaysAgo *FindN `flag:"-ctime"`
@@ -78,13 +81,13 @@ type Find struct {
 	Empty bool `flag:"-empty"`
 
 	// -executable
-	Executable bool `flag:"-executable"`
+	Executable bool `flag:"-executable" dialect:"bsd"`
 
 	// -false
 	False bool `flag:"-false"`
 
 	// -fstype <type>
-	FilesystemType string `flag:"-fstype"`
+	FilesystemType string `flag:"-fstype" dialect:"busybox"`
 
 	// -gid <n>
 	GID *FindN `flag:"-gid"`
@@ -93,7 +96,7 @@ type Find struct {
 	Group string `flag:"-group"`
 
 	// -ilname <pattern>
-	SymlinkTargetCaseInsensitive string `flag:"-ilname"`
+	SymlinkTargetCaseInsensitive string `flag:"-ilname" dialect:"busybox"`
 
 	// -iname <pattern>
 	NameCaseInsensitive string `flag:"-iname"`
@@ -102,16 +105,16 @@ type Find struct {
 	Inode *FindN `flag:"-inum"`
 
 	// -iregex <pattern>
-	RegexCaseInsensitive string `flag:"-iregex"`
+	RegexCaseInsensitive string `flag:"-iregex" dialect:"busybox"`
 
 	// -iwholename <pattern>
-	WholenameCaseInsensitive string `flag:"-iwholename"`
+	WholenameCaseInsensitive string `flag:"-iwholename" dialect:"busybox=-ipath"`
 
 	// -links <n>
 	Links *FindN `flag:"-links"`
 
 	// -lname
-	LinkName string `flag:"-lname"`
+	LinkName string `flag:"-lname" dialect:"busybox"`
 
 	// -mmin <n>
 	ModifiedMinutesAgo *FindN `flag:"-mmin"`
@@ -129,10 +132,10 @@ type Find struct {
 	// TODO -newerXY <reference>
 
 	// -nogroup
-	UnnamedGroup bool `flag:"-nogroup"`
+	UnnamedGroup bool `flag:"-nogroup" dialect:"busybox"`
 
 	// -nouser
-	UnnamedUser bool `flag:"-nouser"`
+	UnnamedUser bool `flag:"-nouser" dialect:"busybox"`
 
 	// -path <pattern>
 	Path string `flag:"-path"`
@@ -147,13 +150,13 @@ type Find struct {
 	ModeMaskAny *int `flag:"-perm" format:"/%o"`
 
 	// -readable
-	Readable bool `flag:"-readable"`
+	Readable bool `flag:"-readable" since:"4.3" dialect:"bsd busybox"`
 
 	// -regex <pattern>
 	Regex string `flag:"-regex"`
 
 	// -samefile <name>
-	SameFile string `flag:"-samefile"`
+	SameFile string `flag:"-samefile" dialect:"bsd"`
 
 	// -size <n>[cwbkMG] (but only byte sizes are supported)
 	// TODO support more than just the c (bytes) suffix.
@@ -169,16 +172,16 @@ type Find struct {
 	UID *FindN `flag:"-uid"`
 
 	// -used <n>
-	Used *FindN `flag:"-used"`
+	Used *FindN `flag:"-used" since:"4.1" dialect:"bsd busybox"`
 
 	// -user <uname>
 	User string `flag:"-user"`
 
 	// -writable
-	Writable bool `flag:"-writable"`
+	Writable bool `flag:"-writable" dialect:"bsd busybox"`
 
 	// -xtype <c>
-	XType FindType `flag:"-xtype"`
+	XType FindType `flag:"-xtype" dialect:"bsd busybox"`
 
 	// -delete
 	Delete bool `flag:"-delete" pos:"last"`
@@ -187,29 +190,29 @@ type Find struct {
 	Exec []string `flag:"-exec" pos:"last"`
 
 	// -execdir <command> ; or -execdir <command> +
-	ExecDir []string `flag:"-execdir" pos:"last"`
+	ExecDir []string `flag:"-execdir" pos:"last" dialect:"busybox"`
 
 	// -fls <file>
-	Fls string `flag:"-fls" pos:"last"`
+	Fls string `flag:"-fls" pos:"last" dialect:"bsd busybox"`
 
 	// -fprint <file>
-	Fprint string `flag:"-fprint" pos:"last"`
+	Fprint string `flag:"-fprint" pos:"last" dialect:"bsd busybox"`
 
 	// -fprint0 <file>
-	Fprint0 string `flag:"-fprint0" pos:"last"`
+	Fprint0 string `flag:"-fprint0" pos:"last" dialect:"bsd busybox"`
 
 	// -fprintf <file> <format>
-	Fprintf [2]string `flag:"-fprintf" pos:"last"`
+	Fprintf [2]string `flag:"-fprintf" pos:"last" dialect:"bsd busybox"`
 
 	// -ls
-	Ls bool `flag:"-ls" pos:"last"`
+	Ls bool `flag:"-ls" pos:"last" dialect:"busybox"`
 
 	// -ok <command> ;
 	// -ok <command> ; or -ok <command> +
 	OK []string `flag:"-ok" pos:"last"`
 
 	// -okdir <command> ; or -okdir <command> +
-	OKDir []string `flag:"-okdir" pos:"last"`
+	OKDir []string `flag:"-okdir" pos:"last" dialect:"busybox"`
 
 	// -print
 	Print bool `flag:"-print" pos:"last"`
@@ -218,7 +221,7 @@ type Find struct {
 	Print0 bool `flag:"-print0" pos:"last"`
 
 	// -printf <format>
-	Printf string `flag:"-printf" pos:"last"`
+	Printf string `flag:"-printf" pos:"last" dialect:"bsd busybox"`
 
 	// -prune
 	Prune bool `flag:"-prune" pos:"last"`
@@ -227,6 +230,12 @@ type Find struct {
 	Quit bool `flag:"-quit" pos:"last"`
 }
 
+// VersionProbe returns the arguments that make find(1) print its version and
+// a pattern that matches it.
+func (Find) VersionProbe() ([]string, string) {
+	return []string{"--version"}, `(\d+(?:\.\d+)+)`
+}
+
 // FindExec is a slice of strings representing the arguments to find(1)'s
 // -exec, -execdir, -ok, and -okdir options, exposed as Exec, ExecDir, OK, and
 // OKDir in Find.
@@ -0,0 +1,8 @@
+package coreutils
+
+// nice(1)
+type Nice struct {
+
+	// -n <adjustment>
+	Adjustment *int `flag:"-n"`
+}
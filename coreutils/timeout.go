@@ -0,0 +1,36 @@
+package coreutils
+
+import (
+	"strconv"
+	"time"
+)
+
+// timeout(1)
+type Timeout struct {
+
+	// --foreground
+	Foreground bool `flag:"--foreground"`
+
+	// -k <duration>
+	KillAfter TimeoutDuration `flag:"-k"`
+
+	// --preserve-status
+	PreserveStatus bool `flag:"--preserve-status"`
+
+	// -s <signal>
+	Signal string `flag:"-s"`
+
+	// -v
+	Verbose bool `flag:"-v"`
+
+	// <duration>
+	Duration TimeoutDuration `pos:"last"`
+}
+
+// TimeoutDuration is a time.Duration formatted in seconds, as timeout(1)
+// expects, rather than as time.Duration.String would.
+type TimeoutDuration time.Duration
+
+func (d TimeoutDuration) String() string {
+	return strconv.FormatFloat(time.Duration(d).Seconds(), 'f', -1, 64) + "s"
+}
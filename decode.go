@@ -0,0 +1,128 @@
+package shellac
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ChannelDecoded connects standard output to a channel of values decoded from
+// each line by dec, alongside any channels, callbacks, or tees already
+// connected.  Blank lines are skipped.  Lines that can't be decoded are
+// skipped, too, and the first *DecodeError is returned by Run if the command
+// otherwise succeeds.
+func ChannelDecoded[T any](cmd *Cmd, ch chan<- T, dec Decoder[T]) {
+	channelDecoded(cmd, ch, dec, nil)
+}
+
+// ChannelDecodedErrors is like ChannelDecoded but sends every *DecodeError to
+// errs instead of returning one from Run.  Both channels are closed when the
+// command exits.
+func ChannelDecodedErrors[T any](
+	cmd *Cmd,
+	ch chan<- T,
+	dec Decoder[T],
+	errs chan<- *DecodeError,
+) {
+	channelDecoded(cmd, ch, dec, errs)
+}
+
+// DecodeError describes a line of output that couldn't be decoded.
+type DecodeError struct {
+	Line int    // the line number, starting with 1
+	Text string // the line itself
+	Err  error  // the error from the Decoder
+}
+
+// Error returns the line number and the Decoder's error but not the line,
+// which might contain secrets.
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("shellac: can't decode line %d: %v", e.Line, e.Err)
+}
+
+// Unwrap returns the Decoder's error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Decoder decodes a line of output, without its trailing newline, into a T.
+type Decoder[T any] interface {
+	Decode(line string) (T, error)
+}
+
+// DecoderFunc is a Decoder that calls a function.
+type DecoderFunc[T any] func(line string) (T, error)
+
+// Decode calls the function.
+func (f DecoderFunc[T]) Decode(line string) (T, error) {
+	return f(line)
+}
+
+// CSVDecoder returns a Decoder that decodes each line as comma-separated
+// values.  Quoted fields can't span lines.
+func CSVDecoder() Decoder[[]string] {
+	return DecoderFunc[[]string](func(line string) ([]string, error) {
+		r := csv.NewReader(strings.NewReader(line))
+		r.FieldsPerRecord = -1
+		return r.Read()
+	})
+}
+
+// JSONLinesDecoder returns a Decoder that decodes each line as JSON, as from
+// go test -json, ip -json, or docker events.
+func JSONLinesDecoder[T any]() Decoder[T] {
+	return DecoderFunc[T](func(line string) (T, error) {
+		var v T
+		err := json.Unmarshal([]byte(line), &v)
+		return v, err
+	})
+}
+
+// TSVDecoder returns a Decoder that decodes each line as tab-separated values.
+// Quotes aren't special.
+func TSVDecoder() Decoder[[]string] {
+	return DecoderFunc[[]string](func(line string) ([]string, error) {
+		return strings.Split(line, "\t"), nil
+	})
+}
+
+// channelDecoded implements ChannelDecoded and ChannelDecodedErrors.
+func channelDecoded[T any](
+	cmd *Cmd,
+	ch chan<- T,
+	dec Decoder[T],
+	errs chan<- *DecodeError,
+) {
+	var first *DecodeError
+	n := 0
+	w := newLineWriter(func(line string) {
+		n++
+		if "" == strings.TrimSuffix(line, "\r") {
+			return
+		}
+		v, err := dec.Decode(line)
+		if nil != err {
+			e := &DecodeError{Line: n, Text: line, Err: err}
+			if nil != errs {
+				errs <- e
+			} else if nil == first {
+				first = e
+			}
+			return
+		}
+		ch <- v
+	})
+	w.close = func() error {
+		close(ch)
+		if nil != errs {
+			close(errs)
+		}
+		if nil != first {
+			return first
+		}
+		return nil
+	}
+	cmd.Stdout = addWriter(cmd.Stdout, os.Stdout, w)
+}
@@ -0,0 +1,84 @@
+package shellac
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+)
+
+type testJSON struct {
+	Action  string
+	Package string
+}
+
+func TestChannelDecodedCSV(t *testing.T) {
+	cmd := toCmd(exec.Command("printf", `a,"b,c"\n\nd,e`))
+	ch := make(chan []string)
+	ChannelDecoded(cmd, ch, CSVDecoder())
+	done := make(chan error)
+	go func() { done <- cmd.Run() }()
+	var records [][]string
+	for record := range ch {
+		records = append(records, record)
+	}
+	if err := <-done; nil != err {
+		t.Fatal(err)
+	}
+	if 2 != len(records) {
+		t.Fatal(records)
+	}
+	testArgs(t, []string{"a", "b,c"}, records[0])
+	testArgs(t, []string{"d", "e"}, records[1])
+}
+
+func TestChannelDecodedErrors(t *testing.T) {
+	cmd := toCmd(exec.Command("printf", `{"Action":"run"}\nnope\n`))
+	ch := make(chan testJSON, 1)
+	errs := make(chan *DecodeError, 1)
+	ChannelDecodedErrors(cmd, ch, JSONLinesDecoder[testJSON](), errs)
+	if err := cmd.Run(); nil != err {
+		t.Fatal(err)
+	}
+	if v := <-ch; "run" != v.Action {
+		t.Fatal(v)
+	}
+	if e := <-errs; nil == e || 2 != e.Line || "nope" != e.Text {
+		t.Fatal(e)
+	}
+	if _, ok := <-errs; ok {
+		t.Fatal(ok)
+	}
+}
+
+func TestChannelDecodedJSONLines(t *testing.T) {
+	cmd := toCmd(exec.Command(
+		"printf",
+		`{"Action":"run","Package":"a"}\nnope\n{"Action":"pass"}\n`,
+	))
+	ch := make(chan testJSON, 2)
+	ChannelDecoded(cmd, ch, JSONLinesDecoder[testJSON]())
+	err := cmd.Run()
+	var e *DecodeError
+	if !errors.As(err, &e) || 2 != e.Line {
+		t.Fatal(err)
+	}
+	if v := <-ch; "run" != v.Action || "a" != v.Package {
+		t.Fatal(v)
+	}
+	if v := <-ch; "pass" != v.Action {
+		t.Fatal(v)
+	}
+	if _, ok := <-ch; ok {
+		t.Fatal(ok)
+	}
+}
+
+func TestChannelDecodedTSV(t *testing.T) {
+	cmd := toCmd(exec.Command("printf", `a\t"b\n`))
+	ch := make(chan []string, 1)
+	ChannelDecoded(cmd, ch, TSVDecoder())
+	if err := cmd.Run(); nil != err {
+		t.Fatal(err)
+	}
+	testArgs(t, []string{"a", `"b`}, <-ch)
+}
@@ -0,0 +1,130 @@
+package shellac
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"reflect"
+	"strings"
+)
+
+// Dialect names one of several implementations of a command, which may spell
+// some flags differently or lack them entirely.  A field's dialect tag lists,
+// separated by spaces, the dialects that lack its flag, as in
+// dialect:"bsd busybox", and those that spell it differently, as in
+// dialect:"busybox=-ipath".
+type Dialect string
+
+var (
+	DialectBSD     Dialect = "bsd" // as in FreeBSD and macOS
+	DialectBusyBox Dialect = "busybox"
+	DialectGNU     Dialect = "gnu"
+)
+
+// Dialects maps commands to their dialects instead of detecting them with
+// DetectDialect.  Keys are as for Binaries.  Set it before building any
+// commands.
+var Dialects = map[string]Dialect{}
+
+// DetectDialect returns the Dialect of the executable that runs the command
+// described by the given interface value, found as by Which, by running its
+// VersionProbe, as InstalledVersion does, or else running it with --version.
+// BusyBox and GNU name themselves in its output; anything else is taken to be
+// BSD.  The output is remembered for each executable so it's only run once.
+func DetectDialect(i interface{}) (Dialect, error) {
+	t := reflect.TypeOf(i)
+	if reflect.Ptr == t.Kind() {
+		t = t.Elem()
+	}
+	pathname, err := which(t)
+	if nil != err {
+		return "", err
+	}
+	return detectDialect(i, t, pathname)
+}
+
+// DialectError describes a flag that has no equivalent in a Dialect.
+type DialectError struct {
+	Command string
+	Dialect Dialect
+	Flag    string
+}
+
+// Error names the command, the flag, and the Dialect.
+func (e *DialectError) Error() string {
+	return fmt.Sprintf(
+		"shellac: %s %s isn't supported by the %s dialect",
+		e.Command,
+		e.Flag,
+		e.Dialect,
+	)
+}
+
+// detectDialect returns the Dialect of the named executable, which runs the
+// command described by the given interface value and struct type.
+func detectDialect(i interface{}, t reflect.Type, pathname string) (Dialect, error) {
+	args := []string{"--version"}
+	if vp, err := versionProbe(i, t); nil == err {
+		args = vp.Args
+	}
+	p, err := probe(pathname, args)
+	var exitErr *exec.ExitError
+	if nil != err && !errors.As(err, &exitErr) {
+		return "", err
+	}
+	switch s := string(p); {
+	case strings.Contains(s, "BusyBox"):
+		return DialectBusyBox, nil
+	case strings.Contains(s, "GNU"):
+		return DialectGNU, nil
+	}
+	return DialectBSD, nil
+}
+
+// dialectArgs returns the arguments to the command described by the given
+// interface value, run by the named executable, in its Dialect, if any field
+// that's set has a dialect tag.  The Dialect is as in Dialects or else
+// detected, unless the command won't run locally, per the given Executor; if
+// it can't be, the arguments are as from Args.
+func dialectArgs(i interface{}, pathname string, e Executor) ([]string, error) {
+	v := reflect.Indirect(reflect.ValueOf(i))
+	t := v.Type()
+	for j := 0; j < t.NumField(); j++ {
+		f := t.Field(j)
+		if "" == f.Tag.Get("dialect") || 0 == len(field(f, v.Field(j))) {
+			continue
+		}
+		d, ok := Dialects[typeName(t)]
+		if !ok {
+			d, ok = Dialects[command(t)]
+		}
+		if !ok && !isLocal(e) {
+			return Args(i), nil
+		}
+		if !ok {
+			var err error
+			if d, err = detectDialect(i, t, pathname); nil != err {
+				return Args(i), nil
+			}
+		}
+		return ArgsDialect(i, d)
+	}
+	return Args(i), nil
+}
+
+// inDialect returns the field with its flag tag replaced as its dialect tag
+// says for the given Dialect or false if it has no equivalent.
+func inDialect(f reflect.StructField, d Dialect) (reflect.StructField, bool) {
+	for _, s := range strings.Fields(f.Tag.Get("dialect")) {
+		name, flag, ok := strings.Cut(s, "=")
+		if Dialect(name) != d {
+			continue
+		}
+		if !ok {
+			return f, false
+		}
+		f.Tag = reflect.StructTag(fmt.Sprintf("flag:%q %s", flag, f.Tag))
+		return f, true
+	}
+	return f, true
+}
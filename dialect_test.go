@@ -0,0 +1,135 @@
+package shellac
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestArgsDialect(t *testing.T) {
+	i := testDialect{Wholename: "*/x"}
+	args, err := ArgsDialect(i, DialectBusyBox)
+	if nil != err {
+		t.Fatal(err)
+	}
+	testArgs(t, []string{"-ipath", "*/x"}, args)
+	args, err = ArgsDialect(i, DialectBSD)
+	if nil != err {
+		t.Fatal(err)
+	}
+	testArgs(t, []string{"-iwholename", "*/x"}, args)
+	testArgs(t, []string{"-iwholename", "*/x"}, Args(i))
+	i.Readable = true
+	var e *DialectError
+	if _, err := ArgsDialect(i, DialectBSD); !errors.As(err, &e) {
+		t.Fatal(err)
+	}
+	if "shellac: testdialect -readable isn't supported by the bsd dialect" != e.Error() {
+		t.Fatal(e)
+	}
+	if _, err := ArgsDialect(i, DialectGNU); nil != err {
+		t.Fatal(err)
+	}
+}
+
+func TestCommandDialect(t *testing.T) {
+	defer func(path []string) { SearchPath = path }(SearchPath)
+	script := testDialectScript("BusyBox v1.36.1 multi-call binary.")
+	SearchPath = []string{testScripts(t, script, "testdialect")}
+	cmd := Command(testDialect{Wholename: "*/x"})
+	if nil != cmd.Err {
+		t.Fatal(cmd.Err)
+	}
+	testArgs(t, []string{"testdialect", "-ipath", "*/x"}, cmd.Args)
+	cmd = Command(testDialect{Readable: true})
+	var e *DialectError
+	if !errors.As(cmd.Err, &e) || DialectBusyBox != e.Dialect {
+		t.Fatal(cmd.Err)
+	}
+	if err := cmd.Run(); cmd.Err != err {
+		t.Fatal(err)
+	}
+}
+
+func TestDetectDialect(t *testing.T) {
+	defer func(path []string) { SearchPath = path }(SearchPath)
+	for output, want := range map[string]Dialect{
+		"BusyBox v1.36.1 multi-call binary.": DialectBusyBox,
+		"testdialect (GNU testutils) 1.0":    DialectGNU,
+		"usage: testdialect [-H | -L | -P]":  DialectBSD,
+	} {
+		script := testDialectScript(output)
+		SearchPath = []string{testScripts(t, script, "testdialect")}
+		d, err := DetectDialect(testDialect{})
+		if nil != err {
+			t.Fatal(err)
+		}
+		if want != d {
+			t.Fatal(output, d)
+		}
+	}
+}
+
+func TestDetectDialectExecutor(t *testing.T) {
+	defer func(path []string) { SearchPath = path }(SearchPath)
+	script := testDialectScript("BusyBox v1.36.1 multi-call binary.")
+	SearchPath = []string{testScripts(t, script, "testdialect")}
+	defer func(e Executor) { DefaultExecutor = e }(DefaultExecutor)
+	DefaultExecutor = DryRunExecutor{}
+	cmd := Command(testDialect{Wholename: "*/x", Readable: true})
+	if nil != cmd.Err {
+		t.Fatal(cmd.Err)
+	}
+	testArgs(t, []string{"testdialect", "-iwholename", "*/x", "-readable"}, cmd.Args)
+}
+
+func TestDetectDialectProbe(t *testing.T) {
+	defer func(path []string) { SearchPath = path }(SearchPath)
+	dirname := testScripts(t, testVersionScript, "testversion")
+	SearchPath = []string{dirname}
+	if _, err := InstalledVersion(testVersion{}); nil != err {
+		t.Fatal(err)
+	}
+	d, err := DetectDialect(testVersion{})
+	if nil != err {
+		t.Fatal(err)
+	}
+	if DialectBSD != d {
+		t.Fatal(d)
+	}
+	p, err := os.ReadFile(filepath.Join(dirname, "probes"))
+	if nil != err {
+		t.Fatal(err)
+	}
+	if "--version\n" != string(p) {
+		t.Fatal(string(p))
+	}
+}
+
+func TestDialects(t *testing.T) {
+	defer func(path []string) { SearchPath = path }(SearchPath)
+	defer func(dialects map[string]Dialect) { Dialects = dialects }(Dialects)
+	script := testDialectScript("testdialect (GNU testutils) 1.0")
+	SearchPath = []string{testScripts(t, script, "testdialect")}
+	if cmd := Command(testDialect{Readable: true}); nil != cmd.Err {
+		t.Fatal(cmd.Err)
+	}
+	Dialects = map[string]Dialect{"testdialect": DialectBSD}
+	cmd := Command(testDialect{Readable: true})
+	var e *DialectError
+	if !errors.As(cmd.Err, &e) || DialectBSD != e.Dialect {
+		t.Fatal(cmd.Err)
+	}
+}
+
+type testDialect struct {
+	Wholename string `flag:"-iwholename" dialect:"busybox=-ipath"`
+	Readable  bool   `flag:"-readable" dialect:"bsd busybox"`
+}
+
+// testDialectScript returns a script that prints the given output and exits
+// non-zero, as most commands do when given --version without supporting it.
+func testDialectScript(output string) string {
+	return "echo '" + output + "' >&2\nexit 1\n"
+}
@@ -0,0 +1,106 @@
+package shellac
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// StderrTailSize is the number of bytes from the end of a command's standard
+// error that are kept for ExitError.
+var StderrTailSize = 4096
+
+// ExitError describes a command that ran but did not exit successfully.  It
+// wraps the *exec.ExitError returned by the os/exec package so errors.As works
+// for either type.
+type ExitError struct {
+	Args     []string      // the command and its arguments, as run
+	ExitCode int           // the exit code or -1 if killed by a signal
+	Signal   os.Signal     // the terminating signal or nil
+	Duration time.Duration // how long the command ran
+	Stderr   []byte        // the redacted tail of standard error
+	Err      error         // the underlying *exec.ExitError
+}
+
+// Error returns the quoted command line, how it exited, how long it ran, and
+// the last line it wrote to standard error, if any.
+func (e *ExitError) Error() string {
+	var status string
+	if nil != e.Signal {
+		status = fmt.Sprintf("killed by signal %v", e.Signal)
+	} else {
+		status = fmt.Sprintf("exited %d", e.ExitCode)
+	}
+	s := fmt.Sprintf(
+		"%s %s after %v",
+		Quote(e.Args),
+		status,
+		e.Duration.Round(time.Millisecond),
+	)
+	if line := lastLine(e.Stderr); "" != line {
+		s = fmt.Sprintf("%s: %s", s, line)
+	}
+	return s
+}
+
+// Unwrap returns the underlying *exec.ExitError.
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// exitError returns an *ExitError describing err, if err is an
+// *exec.ExitError, and err unchanged otherwise.
+func (cmd *Cmd) exitError(err error, d time.Duration, stderr []byte) error {
+	execErr, ok := err.(*exec.ExitError)
+	if !ok {
+		return err
+	}
+	e := &ExitError{
+		Args:     cmd.RedactedArgs(),
+		ExitCode: execErr.ExitCode(),
+		Duration: d,
+		Stderr:   []byte(cmd.Redact(string(stderr))),
+		Err:      err,
+	}
+	if ws, ok := execErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		e.Signal = ws.Signal()
+	}
+	return e
+}
+
+// lastLine returns the last non-empty line in p.
+func lastLine(p []byte) string {
+	p = bytes.TrimRight(p, "\r\n")
+	if i := bytes.LastIndexByte(p, '\n'); -1 != i {
+		p = p[i+1:]
+	}
+	return string(bytes.TrimSpace(p))
+}
+
+// tailWriter is an io.Writer that keeps only the last n bytes written to it.
+type tailWriter struct {
+	buffer []byte
+	n      int
+}
+
+// newTailWriter constructs a tailWriter that keeps the last n bytes.
+func newTailWriter(n int) *tailWriter {
+	return &tailWriter{n: n}
+}
+
+// Bytes returns a copy of the last n bytes written.
+func (w *tailWriter) Bytes() []byte {
+	return append([]byte{}, w.buffer...)
+}
+
+// Write appends p to the buffer and discards all but the last n bytes.
+func (w *tailWriter) Write(p []byte) (int, error) {
+	w.buffer = append(w.buffer, p...)
+	if len(w.buffer) > w.n {
+		w.buffer = w.buffer[len(w.buffer)-w.n:]
+	}
+	return len(p), nil
+}
@@ -0,0 +1,62 @@
+package shellac
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestExitError(t *testing.T) {
+	err := Run(exec.Command("sh", "-c", "echo oops >&2; exit 3"))
+	var e *ExitError
+	if !errors.As(err, &e) {
+		t.Fatal(err)
+	}
+	if 3 != e.ExitCode || nil != e.Signal {
+		t.Fatal(e)
+	}
+	if "oops\n" != string(e.Stderr) {
+		t.Fatal(e.Stderr)
+	}
+	if !strings.HasPrefix(e.Error(), "sh -c 'echo oops >&2; exit 3' exited 3 after ") {
+		t.Fatal(e)
+	}
+	if !strings.HasSuffix(e.Error(), ": oops") {
+		t.Fatal(e)
+	}
+	var execErr *exec.ExitError
+	if !errors.As(err, &execErr) {
+		t.Fatal(err)
+	}
+}
+
+func TestExitErrorSignal(t *testing.T) {
+	err := Run(exec.Command("sh", "-c", "kill -TERM $$"))
+	var e *ExitError
+	if !errors.As(err, &e) {
+		t.Fatal(err)
+	}
+	if -1 != e.ExitCode || syscall.SIGTERM != e.Signal {
+		t.Fatal(e)
+	}
+	if !strings.Contains(e.Error(), "killed by signal terminated") {
+		t.Fatal(e)
+	}
+}
+
+func TestExitErrorStderrTail(t *testing.T) {
+	w := newTailWriter(4)
+	w.Write([]byte("foo\n"))
+	w.Write([]byte("bar\n"))
+	if "bar\n" != string(w.Bytes()) {
+		t.Fatal(w.Bytes())
+	}
+}
+
+func TestExitErrorSuccess(t *testing.T) {
+	if err := Run(exec.Command("true")); nil != err {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,67 @@
+package shellac
+
+import (
+	"sync"
+	"time"
+)
+
+// Stream is an enumeration of where an Event came from.
+type Stream string
+
+var (
+	StreamStdout Stream = "stdout"
+	StreamStderr Stream = "stderr"
+	StreamExit   Stream = "exit"
+)
+
+// Event is a line of standard output or error or, last of all, the command's
+// exit.
+type Event struct {
+	Stream Stream
+	Line   string    // without its trailing newline; empty for StreamExit
+	Time   time.Time // when the line was read or the command exited
+	Err    error     // the error returned by Run; only for StreamExit
+}
+
+// Events connects standard output and error, alongside any channels,
+// callbacks, or tees already connected, to a channel of Events, one per line,
+// followed by a StreamExit Event, after which the channel is closed.
+// Lines are sent in the order they're read, which is as close to the order
+// they were written as two pipes allow.
+func (cmd *Cmd) Events() <-chan Event {
+	ch := make(chan Event)
+	var mu sync.Mutex
+	send := func(stream Stream) func(string) {
+		return func(s string) {
+			mu.Lock()
+			defer mu.Unlock()
+			ch <- Event{Stream: stream, Line: s, Time: time.Now()}
+		}
+	}
+	cmd.OnStdoutLine(send(StreamStdout))
+	cmd.OnStderrLine(send(StreamStderr))
+	cmd.atExit(func(err error) {
+		ch <- Event{Stream: StreamExit, Time: time.Now(), Err: err}
+		close(ch)
+	})
+	return ch
+}
+
+// atExit arranges for fn to be called with the command's error after it exits
+// and standard output and error are closed.
+func (cmd *Cmd) atExit(fn func(error)) {
+	cmd.exitFuncs = append(cmd.exitFuncs, fn)
+}
+
+// exit closes standard output and error and calls each function registered
+// by atExit.  It returns err or, if that's nil, the first error from closing
+// standard output and error, as from ChannelDecoded.
+func (cmd *Cmd) exit(err error) error {
+	if e := cmd.closeStdoutStderr(); nil == err {
+		err = e
+	}
+	for _, fn := range cmd.exitFuncs {
+		fn(err)
+	}
+	return err
+}
@@ -0,0 +1,76 @@
+package shellac
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+)
+
+func TestEvents(t *testing.T) {
+	cmd := toCmd(exec.Command("sh", "-c", "echo a; echo b >&2; printf c"))
+	ch := cmd.Events()
+	go cmd.Run()
+	var stdout, stderr []string
+	var exit *Event
+	for e := range ch {
+		if nil != exit {
+			t.Fatal(e)
+		}
+		if e.Time.IsZero() {
+			t.Fatal(e)
+		}
+		switch e.Stream {
+		case StreamStdout:
+			stdout = append(stdout, e.Line)
+		case StreamStderr:
+			stderr = append(stderr, e.Line)
+		case StreamExit:
+			exit = &e
+		}
+	}
+	if 2 != len(stdout) || "a" != stdout[0] || "c" != stdout[1] {
+		t.Fatal(stdout)
+	}
+	if 1 != len(stderr) || "b" != stderr[0] {
+		t.Fatal(stderr)
+	}
+	if nil == exit || nil != exit.Err {
+		t.Fatal(exit)
+	}
+}
+
+func TestEventsExitError(t *testing.T) {
+	cmd := toCmd(exec.Command("sh", "-c", "echo oops >&2; exit 3"))
+	ch := cmd.Events()
+	go cmd.Run()
+	var events []Event
+	for e := range ch {
+		events = append(events, e)
+	}
+	if 2 != len(events) {
+		t.Fatal(events)
+	}
+	if StreamStderr != events[0].Stream || "oops" != events[0].Line {
+		t.Fatal(events[0])
+	}
+	var e *ExitError
+	if StreamExit != events[1].Stream || !errors.As(events[1].Err, &e) {
+		t.Fatal(events[1])
+	}
+	if 3 != e.ExitCode || "oops" != string(e.Stderr[:4]) {
+		t.Fatal(e)
+	}
+}
+
+func TestEventsNotFound(t *testing.T) {
+	cmd := toCmd(exec.Command("shellac-not-found"))
+	ch := cmd.Events()
+	go cmd.Run()
+	e, ok := <-ch
+	if !ok || StreamExit != e.Stream || nil == e.Err {
+		t.Fatal(e)
+	}
+	if _, ok := <-ch; ok {
+		t.Fatal(ok)
+	}
+}
@@ -1,14 +1,30 @@
-package shellac
+package shellac_test
 
 import (
 	"fmt"
+	"github.com/rcrowley/go-shellac"
 	"github.com/rcrowley/go-shellac/coreutils"
 	"github.com/rcrowley/go-shellac/ssh"
 	"os/user"
 )
 
+func ExampleCmd_LinesSeq() {
+	cmd := shellac.Command(coreutils.Find{
+		Dirnames: []string{"."},
+		Name:     "*.go",
+		Type:     coreutils.FindFile,
+	})
+	for s, err := range cmd.LinesSeq() {
+		if nil != err {
+			fmt.Println(err)
+			break
+		}
+		fmt.Println(s)
+	}
+}
+
 func ExampleFind() {
-	Run(coreutils.Find{
+	shellac.Run(coreutils.Find{
 		Dirnames: []string{"."},
 		Name:     "*.go",
 		Type:     coreutils.FindFile,
@@ -18,7 +34,7 @@ func ExampleFind() {
 func ExampleFindChannel() {
 	ch := make(chan string)
 	go func() {
-		cmd := Command(coreutils.Find{
+		cmd := shellac.Command(coreutils.Find{
 			Dirnames: []string{"."},
 			Name:     "*.go",
 			Type:     coreutils.FindFile,
@@ -33,7 +49,7 @@ func ExampleFindChannel() {
 
 func ExampleSSH() {
 	u, _ := user.Current()
-	Run(ssh.SSH{
+	shellac.Run(ssh.SSH{
 		AgentForwarding: true,
 		Command:         []string{"hostname"},
 		Hostname:        "example.com",
@@ -43,7 +59,7 @@ func ExampleSSH() {
 }
 
 func ExampleSudoFind() {
-	Sudo(coreutils.Find{
+	shellac.Sudo(coreutils.Find{
 		Dirnames: []string{"/dev"},
 		Readable: true,
 		Type:     coreutils.FindBlock,
@@ -0,0 +1,207 @@
+package shellac
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultExecutor is the Executor used by any Cmd whose Executor is nil.
+var DefaultExecutor Executor = LocalExecutor{}
+
+// Executor starts a Cmd.  Cmd takes care of logging, waiting, and closing
+// channels, including for Run and Output; the Executor only has to start the
+// command, connect standard input, output, and error, and return a Process
+// whose Wait returns an *ExitError if it fails.
+//
+// Commands are only resolved, as by Command, when the Executor runs them
+// locally.  An Executor that runs commands with another, as
+// shellactest.RecordingExecutor does, may have an Unwrap method that returns
+// it so that they're resolved as that Executor would run them.
+type Executor interface {
+	Start(cmd *Cmd) (Process, error)
+}
+
+// Process is a command started by an Executor.
+type Process interface {
+	Pid() int
+	Signal(sig os.Signal) error
+	Wait() error
+}
+
+// DryRunExecutor is an Executor that doesn't run anything.  Commands are
+// still logged by Cmd so this is a good way to see what a program would do.
+type DryRunExecutor struct{}
+
+// Start returns a Process that has already exited successfully.
+func (DryRunExecutor) Start(cmd *Cmd) (Process, error) {
+	return doneProcess{}, nil
+}
+
+// FakeExecutor is an Executor that doesn't run anything.  Instead, it responds
+// to each command with the next of its Responses in order.
+type FakeExecutor struct {
+	Responses []FakeResponse
+	mu        sync.Mutex
+}
+
+// NewFakeExecutor constructs a FakeExecutor that responds with the given
+// responses in order.
+func NewFakeExecutor(responses ...FakeResponse) *FakeExecutor {
+	return &FakeExecutor{Responses: responses}
+}
+
+// Start returns a Process that writes the next response's standard output and
+// error and exits with its exit code.
+func (e *FakeExecutor) Start(cmd *Cmd) (Process, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if 0 == len(e.Responses) {
+		return nil, fmt.Errorf(
+			"shellac: FakeExecutor has no response for %s",
+			cmd,
+		)
+	}
+	r := e.Responses[0]
+	e.Responses = e.Responses[1:]
+	return r.start(cmd), nil
+}
+
+// FakeResponse is the scripted result of a command run by FakeExecutor.
+type FakeResponse struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+// start writes the response's standard output and error in the background,
+// as a real process would, and returns a Process that waits for it.
+func (r FakeResponse) start(cmd *Cmd) Process {
+	p := &fakeProcess{done: make(chan struct{})}
+	go func() {
+		defer close(p.done)
+		if err := fakeWrite(cmd.Stdout, r.Stdout); nil != err {
+			p.err = err
+			return
+		}
+		if err := fakeWrite(cmd.Stderr, r.Stderr); nil != err {
+			p.err = err
+			return
+		}
+		if 0 != r.ExitCode {
+			p.err = &ExitError{
+				Args:     cmd.RedactedArgs(),
+				ExitCode: r.ExitCode,
+				Stderr:   []byte(cmd.Redact(r.Stderr)),
+			}
+		}
+	}()
+	return p
+}
+
+// LocalExecutor is an Executor that runs commands as local processes via the
+// os/exec package.  It's the default.
+type LocalExecutor struct{}
+
+// Start starts the command.  The tail of standard error is captured for any
+// *ExitError, even if standard error is also connected elsewhere.
+func (LocalExecutor) Start(cmd *Cmd) (Process, error) {
+	p := &localProcess{
+		cmd:    cmd,
+		stdout: cmd.Stdout,
+		stderr: cmd.Stderr,
+		tail:   newTailWriter(StderrTailSize),
+	}
+	if nil == p.stderr {
+		cmd.Stderr = p.tail
+	} else {
+		cmd.Stderr = io.MultiWriter(p.stderr, p.tail)
+	}
+	if nil != p.stdout && isSameWriter(p.stdout, p.stderr) {
+		cmd.Stdout = cmd.Stderr // so exec keeps calling Write serially
+	}
+	p.start = time.Now()
+	if err := cmd.Cmd.Start(); nil != err {
+		p.restore()
+		return nil, err
+	}
+	return p, nil
+}
+
+// isLocal returns true if the Executor, or the one it unwraps to, runs
+// commands as local processes, so that probing the executables that run them
+// is harmless.
+func isLocal(e Executor) bool {
+	for {
+		switch w := e.(type) {
+		case LocalExecutor, *LocalExecutor:
+			return true
+		case interface{ Unwrap() Executor }:
+			e = w.Unwrap()
+		default:
+			return false
+		}
+	}
+}
+
+// doneProcess is a Process that has already exited successfully.
+type doneProcess struct{}
+
+func (doneProcess) Pid() int { return 0 }
+
+func (doneProcess) Signal(sig os.Signal) error { return nil }
+
+func (doneProcess) Wait() error { return nil }
+
+// fakeProcess is a Process started by FakeExecutor.
+type fakeProcess struct {
+	done chan struct{}
+	err  error
+}
+
+func (p *fakeProcess) Pid() int { return 0 }
+
+func (p *fakeProcess) Signal(sig os.Signal) error { return nil }
+
+func (p *fakeProcess) Wait() error {
+	<-p.done
+	return p.err
+}
+
+// localProcess is a Process started by LocalExecutor.
+type localProcess struct {
+	cmd            *Cmd
+	start          time.Time
+	stdout, stderr io.Writer
+	tail           *tailWriter
+}
+
+func (p *localProcess) Pid() int {
+	return p.cmd.Process.Pid
+}
+
+func (p *localProcess) Signal(sig os.Signal) error {
+	return p.cmd.Process.Signal(sig)
+}
+
+func (p *localProcess) Wait() error {
+	err := p.cmd.Cmd.Wait()
+	p.restore()
+	return p.cmd.exitError(err, time.Since(p.start), p.tail.Bytes())
+}
+
+// restore reconnects standard output and error as they were before Start.
+func (p *localProcess) restore() {
+	p.cmd.Stdout, p.cmd.Stderr = p.stdout, p.stderr
+}
+
+// fakeWrite writes s to w, if both are non-empty.
+func fakeWrite(w io.Writer, s string) error {
+	if nil == w || "" == s {
+		return nil
+	}
+	_, err := io.WriteString(w, s)
+	return err
+}
@@ -0,0 +1,90 @@
+package shellac
+
+import (
+	"errors"
+	"github.com/rcrowley/go-shellac/coreutils"
+	"testing"
+)
+
+func TestDefaultExecutor(t *testing.T) {
+	defer func(e Executor) { DefaultExecutor = e }(DefaultExecutor)
+	DefaultExecutor = NewFakeExecutor(FakeResponse{Stdout: "./fake.go\n"})
+	lines, err := Command(coreutils.Find{Dirnames: []string{"."}}).Lines()
+	if nil != err {
+		t.Fatal(err)
+	}
+	testArgs(t, []string{"./fake.go"}, lines)
+}
+
+func TestDryRunExecutor(t *testing.T) {
+	cmd := Command(test{})
+	cmd.Executor = DryRunExecutor{}
+	if err := cmd.Run(); nil != err {
+		t.Fatal(err)
+	}
+	if nil != cmd.ProcessState {
+		t.Fatal(cmd.ProcessState)
+	}
+}
+
+func TestDryRunExecutorErr(t *testing.T) {
+	defer func(e Executor) { DefaultExecutor = e }(DefaultExecutor)
+	DefaultExecutor = DryRunExecutor{}
+	cmd := CommandDialect(coreutils.Find{Printf: "%p"}, DialectBSD)
+	var e *DialectError
+	if err := cmd.Run(); !errors.As(err, &e) || cmd.Err != err {
+		t.Fatal(err)
+	}
+	select {
+	case <-cmd.Done():
+	default:
+		t.Fatal("not done")
+	}
+	cmd = Command(testDefault{})
+	if nil != cmd.Err {
+		t.Fatal(cmd.Err)
+	}
+	if err := cmd.Run(); nil != err {
+		t.Fatal(err)
+	}
+}
+
+func TestFakeExecutor(t *testing.T) {
+	e := NewFakeExecutor(
+		FakeResponse{Stdout: "hi\n"},
+		FakeResponse{Stderr: "oops\n", ExitCode: 2},
+	)
+	cmd := Command(test{Flag: "hi"})
+	cmd.Executor = e
+	p, err := cmd.Output()
+	if nil != err {
+		t.Fatal(err)
+	}
+	if "hi\n" != string(p) {
+		t.Fatal(string(p))
+	}
+	cmd = Command(test{Flag: "hi"})
+	cmd.Executor = e
+	err = cmd.Run()
+	if ee, ok := err.(*ExitError); !ok || 2 != ee.ExitCode {
+		t.Fatal(err)
+	}
+	cmd = Command(test{Flag: "hi"})
+	cmd.Executor = e
+	if err := cmd.Run(); nil == err {
+		t.Fatal(err)
+	}
+}
+
+func TestFakeExecutorChannelStdout(t *testing.T) {
+	ch := make(chan string)
+	cmd := Command(test{})
+	cmd.Executor = NewFakeExecutor(FakeResponse{Stdout: "foo\nbar\n"})
+	cmd.ChannelStdout(ch)
+	go cmd.Run()
+	lines := []string{}
+	for s := range ch {
+		lines = append(lines, s)
+	}
+	testArgs(t, []string{"foo", "bar"}, lines)
+}
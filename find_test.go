@@ -1,7 +1,10 @@
 package shellac
 
 import (
+	"errors"
 	"github.com/rcrowley/go-shellac/coreutils"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -50,6 +53,26 @@ func TestFindN(t *testing.T) {
 	}))
 }
 
+func TestFindPrint0(t *testing.T) {
+	dirname := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dirname, "a\nb"), nil, 0644); nil != err {
+		t.Fatal(err)
+	}
+	ch := make(chan string)
+	cmd := Command(coreutils.Find{
+		Dirnames: []string{dirname},
+		Print0:   true,
+		Type:     coreutils.FindFile,
+	})
+	cmd.ChannelStdoutDelim(ch, 0)
+	go cmd.Run()
+	lines := []string{}
+	for s := range ch {
+		lines = append(lines, s)
+	}
+	testArgs(t, []string{filepath.Join(dirname, "a\nb")}, lines)
+}
+
 func TestFindSymlinks(t *testing.T) {
 	testArgs(t, []string{"-P"}, Args(coreutils.Find{
 		DoNotFollowSymlinks: true,
@@ -60,3 +83,34 @@ func TestFindSymlinks(t *testing.T) {
 		FollowInitialSymlinks: true,
 	}))
 }
+
+func TestFindVersion(t *testing.T) {
+	version, err := InstalledVersion(coreutils.Find{})
+	if nil != err {
+		t.Skip(err)
+	}
+	if 0 > version.Compare(Version{4, 3}) {
+		t.Skip(version)
+	}
+	cmd := Command(coreutils.Find{Dirnames: []string{"."}, Readable: true})
+	if nil != cmd.Err {
+		t.Fatal(cmd.Err)
+	}
+}
+
+func TestFindDialect(t *testing.T) {
+	args, err := ArgsDialect(coreutils.Find{
+		Dirnames:                 []string{"."},
+		WholenameCaseInsensitive: "*/vendor/*",
+		Prune:                    true,
+	}, DialectBusyBox)
+	if nil != err {
+		t.Fatal(err)
+	}
+	testArgs(t, []string{".", "-ipath", "*/vendor/*", "-prune"}, args)
+	_, err = ArgsDialect(coreutils.Find{Printf: "%p\n"}, DialectBSD)
+	var e *DialectError
+	if !errors.As(err, &e) || "-printf" != e.Flag {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,75 @@
+package shellac
+
+import "fmt"
+
+// Hooks are called for every Cmd, before each Cmd's own Hooks.  Set them
+// before running any commands.
+var Hooks []Hook
+
+// Hook observes commands as they start and exit, as for metrics or tracing,
+// and may veto them.  A panic in a Hook is returned as an error and, if the
+// command has started, kills it.  Embed NopHook to implement only some of
+// these methods.
+type Hook interface {
+	// BeforeStart is called before the command starts.  If it returns an
+	// error, the command isn't started and the error is returned instead.
+	BeforeStart(cmd *Cmd) error
+
+	// AfterStart is called after the command starts with its process ID, which
+	// is 0 if it isn't a local process.
+	AfterStart(cmd *Cmd, pid int)
+
+	// AfterExit is called after the command exits or fails to start and
+	// standard output and error are closed.
+	AfterExit(cmd *Cmd, r *Result)
+}
+
+// NopHook is a Hook that does nothing and vetoes nothing.
+type NopHook struct{}
+
+func (NopHook) BeforeStart(cmd *Cmd) error { return nil }
+
+func (NopHook) AfterStart(cmd *Cmd, pid int) {}
+
+func (NopHook) AfterExit(cmd *Cmd, r *Result) {}
+
+// afterExit calls AfterExit on every Hook.
+func (cmd *Cmd) afterExit(r *Result) error {
+	return cmd.callHooks(func(h Hook) error {
+		h.AfterExit(cmd, r)
+		return nil
+	})
+}
+
+// afterStart calls AfterStart on every Hook.
+func (cmd *Cmd) afterStart(pid int) error {
+	return cmd.callHooks(func(h Hook) error {
+		h.AfterStart(cmd, pid)
+		return nil
+	})
+}
+
+// beforeStart notes which Hooks to call for the rest of this run, calls
+// BeforeStart on each, and returns the first error.
+func (cmd *Cmd) beforeStart() error {
+	cmd.hooks = append(append([]Hook{}, Hooks...), cmd.Hooks...)
+	return cmd.callHooks(func(h Hook) error {
+		return h.BeforeStart(cmd)
+	})
+}
+
+// callHooks calls fn with each global Hook and then each of the command's
+// Hooks until one returns an error or panics.
+func (cmd *Cmd) callHooks(fn func(Hook) error) (err error) {
+	defer func() {
+		if r := recover(); nil != r {
+			err = fmt.Errorf("shellac: hook panicked: %v", r)
+		}
+	}()
+	for _, h := range cmd.hooks {
+		if err := fn(h); nil != err {
+			return err
+		}
+	}
+	return nil
+}
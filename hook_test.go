@@ -0,0 +1,88 @@
+package shellac
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHooks(t *testing.T) {
+	defer func(hooks []Hook) { Hooks = hooks }(Hooks)
+	global := &testHook{}
+	Hooks = []Hook{global}
+	cmd := toCmd(exec.Command("sh", "-c", "exit 3"))
+	h := &testHook{}
+	cmd.Hooks = []Hook{h}
+	cmd.Run()
+	for _, h := range []*testHook{global, h} {
+		if 1 != h.before || 0 == h.pid || nil == h.result {
+			t.Fatal(h)
+		}
+		if 3 != h.result.ExitCode {
+			t.Fatal(h.result)
+		}
+	}
+}
+
+func TestHooksPanic(t *testing.T) {
+	cmd := toCmd(exec.Command("sleep", "10"))
+	h := &testHook{panicAfterStart: true}
+	cmd.Hooks = []Hook{h}
+	start := time.Now()
+	err := cmd.Run()
+	if nil == err || !strings.Contains(err.Error(), "hook panicked: oops") {
+		t.Fatal(err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatal(time.Since(start))
+	}
+	if nil == cmd.ProcessState || cmd.ProcessState.Success() {
+		t.Fatal(cmd.ProcessState)
+	}
+	if nil == h.result || err != h.result.Err {
+		t.Fatal(h.result)
+	}
+}
+
+func TestHooksVeto(t *testing.T) {
+	errVeto := errors.New("veto")
+	cmd := toCmd(exec.Command("touch", t.TempDir()+"/vetoed"))
+	h := &testHook{err: errVeto}
+	cmd.Hooks = []Hook{&testHook{}, h, &testHook{}}
+	if err := cmd.Run(); errVeto != err {
+		t.Fatal(err)
+	}
+	if nil != cmd.Process || 0 != h.pid || nil == h.result {
+		t.Fatal(h)
+	}
+	if 0 != cmd.Hooks[2].(*testHook).before {
+		t.Fatal(cmd.Hooks[2])
+	}
+}
+
+type testHook struct {
+	NopHook
+	before          int
+	err             error
+	panicAfterStart bool
+	pid             int
+	result          *Result
+}
+
+func (h *testHook) BeforeStart(cmd *Cmd) error {
+	h.before++
+	return h.err
+}
+
+func (h *testHook) AfterStart(cmd *Cmd, pid int) {
+	h.pid = pid
+	if h.panicAfterStart {
+		panic("oops")
+	}
+}
+
+func (h *testHook) AfterExit(cmd *Cmd, r *Result) {
+	h.result = r
+}
@@ -0,0 +1,166 @@
+package shellac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultLogger is the Logger used by any Cmd whose Logger is nil.  Set it to
+// nil to stop logging commands altogether.
+var DefaultLogger Logger = NewTextLogger(os.Stderr)
+
+// Logger logs commands as they start and exit.  Loggers must not panic.
+type Logger interface {
+	LogStart(cmd *Cmd)
+	LogExit(cmd *Cmd, d time.Duration, err error)
+}
+
+// NopLogger is a Logger that doesn't log anything.  Use it to stop logging a
+// particular Cmd.
+type NopLogger struct{}
+
+func (NopLogger) LogStart(cmd *Cmd) {}
+
+func (NopLogger) LogExit(cmd *Cmd, d time.Duration, err error) {}
+
+// SlogLogger is a Logger that logs commands as structured records via the
+// log/slog package.
+type SlogLogger struct {
+	Logger *slog.Logger // if nil, nothing is logged
+	Level  slog.Level   // the level of successful commands; failures are errors
+}
+
+// NewSlogLogger constructs a SlogLogger that logs to l at the info level.
+func NewSlogLogger(l *slog.Logger) *SlogLogger {
+	return &SlogLogger{Logger: l, Level: slog.LevelInfo}
+}
+
+// LogStart logs the command's arguments, directory, and whether it's run via
+// sudo(8) or another Escalator.
+func (l *SlogLogger) LogStart(cmd *Cmd) {
+	if nil == l.Logger {
+		return
+	}
+	l.Logger.LogAttrs(
+		context.Background(),
+		l.Level,
+		"shellac: start",
+		cmdAttrs(cmd)...,
+	)
+}
+
+// LogExit logs the same as LogStart plus how long the command ran, the CPU
+// time and memory it used, its exit code, and any error.  Commands that are
+// over their Budget are logged as warnings.
+func (l *SlogLogger) LogExit(cmd *Cmd, d time.Duration, err error) {
+	if nil == l.Logger {
+		return
+	}
+	level, attrs := l.Level, append(
+		cmdAttrs(cmd),
+		slog.Duration("duration", d),
+		slog.Int("exit_code", exitCode(err)),
+	)
+	if r := cmd.Result(); nil != r {
+		attrs = append(
+			attrs,
+			slog.Duration("user_time", r.UserTime),
+			slog.Duration("system_time", r.SystemTime),
+			slog.Int64("max_rss", r.MaxRSS),
+		)
+		if 0 != len(r.OverBudget) {
+			level = max(level, slog.LevelWarn)
+			attrs = append(attrs, slog.Any("over_budget", r.OverBudget))
+		}
+	}
+	if nil != err {
+		level = slog.LevelError
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	l.Logger.LogAttrs(context.Background(), level, "shellac: exit", attrs...)
+}
+
+// TextLogger is a Logger that writes each command line as it starts, bolded
+// if Color is true, sort of like what make(1) or sh(1) with -x do.
+type TextLogger struct {
+	Color  bool
+	Prefix string // written before each line, as by RunAll
+	W      io.Writer
+}
+
+// NewTextLogger constructs a TextLogger that writes to w, in color if w is a
+// TTY and the NO_COLOR environment variable isn't set.
+func NewTextLogger(w io.Writer) *TextLogger {
+	return &TextLogger{Color: isTTY(w) && "" == os.Getenv("NO_COLOR"), W: w}
+}
+
+// LogStart writes the prefix and the quoted command line.
+func (l *TextLogger) LogStart(cmd *Cmd) {
+	if nil == l.W {
+		return
+	}
+	format := "%s%s\n"
+	if l.Color {
+		format = "%s\033[1m%s\033[0m\n"
+	}
+	fmt.Fprintf(l.W, format, l.Prefix, cmd)
+}
+
+// LogExit writes a warning if the command was over its Budget and nothing
+// otherwise.  Errors are returned to the caller to handle.
+func (l *TextLogger) LogExit(cmd *Cmd, d time.Duration, err error) {
+	r := cmd.Result()
+	if nil == l.W || nil == r || 0 == len(r.OverBudget) {
+		return
+	}
+	fmt.Fprintf(
+		l.W,
+		"%s%s: over budget: %s\n",
+		l.Prefix,
+		cmd,
+		strings.Join(r.OverBudget, ", "),
+	)
+}
+
+// cmdAttrs returns slog attributes describing the command.
+func cmdAttrs(cmd *Cmd) []slog.Attr {
+	return []slog.Attr{
+		slog.Any("argv", cmd.RedactedArgs()),
+		slog.String("dir", cmd.Dir),
+		slog.Bool("sudo", 0 != len(cmd.sudo)),
+	}
+}
+
+// exitCode returns 0 if err is nil, the exit code if err is an *ExitError,
+// and -1 otherwise.
+func exitCode(err error) int {
+	if nil == err {
+		return 0
+	}
+	var e *ExitError
+	if errors.As(err, &e) {
+		return e.ExitCode
+	}
+	return -1
+}
+
+// isTTY returns true if w is a file that's a character device.  Files that
+// can't be stat'ed, as happens when a daemon's standard error is closed,
+// aren't TTYs.
+func isTTY(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok || nil == f {
+		return false
+	}
+	fi, err := f.Stat()
+	if nil != err {
+		return false
+	}
+	return 0 != fi.Mode()&os.ModeCharDevice
+}
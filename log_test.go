@@ -0,0 +1,96 @@
+package shellac
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"os/exec"
+	"testing"
+)
+
+func TestLogNil(t *testing.T) {
+	defer func(l Logger) { DefaultLogger = l }(DefaultLogger)
+	DefaultLogger = nil
+	if err := Run(exec.Command("true")); nil != err {
+		t.Fatal(err)
+	}
+}
+
+func TestSlogLogger(t *testing.T) {
+	var b bytes.Buffer
+	cmd := toCmd(exec.Command("sh", "-c", "exit 3"))
+	cmd.Dir = os.TempDir()
+	cmd.Logger = NewSlogLogger(slog.New(slog.NewJSONHandler(&b, nil)))
+	cmd.Run()
+	dec := json.NewDecoder(&b)
+	var start, exit map[string]interface{}
+	if err := dec.Decode(&start); nil != err {
+		t.Fatal(err)
+	}
+	if "INFO" != start["level"] || "shellac: start" != start["msg"] {
+		t.Fatal(start)
+	}
+	if os.TempDir() != start["dir"] || false != start["sudo"] {
+		t.Fatal(start)
+	}
+	if err := dec.Decode(&exit); nil != err {
+		t.Fatal(err)
+	}
+	if "ERROR" != exit["level"] || "shellac: exit" != exit["msg"] {
+		t.Fatal(exit)
+	}
+	if 3.0 != exit["exit_code"] || nil == exit["duration"] {
+		t.Fatal(exit)
+	}
+	if nil == exit["user_time"] || nil == exit["max_rss"] {
+		t.Fatal(exit)
+	}
+	argv, ok := exit["argv"].([]interface{})
+	if !ok || 3 != len(argv) || "exit 3" != argv[2] {
+		t.Fatal(exit)
+	}
+}
+
+func TestSlogLoggerNil(t *testing.T) {
+	cmd := toCmd(exec.Command("true"))
+	cmd.Logger = NewSlogLogger(nil)
+	if err := cmd.Run(); nil != err {
+		t.Fatal(err)
+	}
+}
+
+func TestTextLogger(t *testing.T) {
+	var b bytes.Buffer
+	l := NewTextLogger(&b)
+	if l.Color {
+		t.Fatal(l)
+	}
+	l.LogStart(toCmd(exec.Command("find", ".", "-name", "*.go")))
+	if "find . -name '*.go'\n" != b.String() {
+		t.Fatal(b.String())
+	}
+}
+
+func TestTextLoggerClosed(t *testing.T) {
+	r, w, err := os.Pipe()
+	if nil != err {
+		t.Fatal(err)
+	}
+	r.Close()
+	w.Close()
+	l := NewTextLogger(w)
+	if l.Color {
+		t.Fatal(l)
+	}
+	l.LogStart(toCmd(exec.Command("true")))
+}
+
+func TestTextLoggerColor(t *testing.T) {
+	var b bytes.Buffer
+	l := &TextLogger{Color: true, W: &b}
+	l.LogStart(toCmd(exec.Command("true")))
+	if "\033[1mtrue\033[0m\n" != b.String() {
+		t.Fatal(b.String())
+	}
+}
@@ -0,0 +1,248 @@
+package shellac
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// Policy is a Hook that vetoes commands that break its rules, as when running
+// commands on behalf of plugins.  Rules about fields are checked against the
+// structs that describe the command and any wrappers and escalators; rules
+// about arguments are checked against the final command line.  Commands run on
+// other hosts, as by ssh.Remote, are checked, too.
+type Policy struct {
+	NopHook
+
+	// Commands, if non-empty, lists the only programs, by name, that may be
+	// run, including wrappers and escalators.
+	Commands []string `json:"commands,omitempty"`
+
+	// SudoUsers, if non-empty, lists the only users that escalators may run
+	// commands as.  Escalators without a User run commands as root.
+	SudoUsers []string `json:"sudo_users,omitempty"`
+
+	// Forbid lists rules that each forbid a field or argument.
+	Forbid []PolicyRule `json:"forbid,omitempty"`
+}
+
+// LoadPolicy reads a Policy from the named JSON file.
+func LoadPolicy(pathname string) (*Policy, error) {
+	p, err := os.ReadFile(pathname)
+	if nil != err {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.DisallowUnknownFields()
+	policy := &Policy{}
+	if err := dec.Decode(policy); nil != err {
+		return nil, fmt.Errorf("%s: %v", pathname, err)
+	}
+	for _, rule := range policy.Forbid {
+		if err := rule.validate(); nil != err {
+			return nil, fmt.Errorf("%s: %v", pathname, err)
+		}
+	}
+	return policy, nil
+}
+
+// BeforeStart vetoes the command with a *PolicyError if it breaks any rule.
+func (p *Policy) BeforeStart(cmd *Cmd) error {
+	return p.Check(cmd)
+}
+
+// Check returns a *PolicyError naming the first rule the command breaks or
+// nil if it breaks none.
+func (p *Policy) Check(cmd *Cmd) error {
+	return p.check(cmd, cmd, false)
+}
+
+// check checks c, which is cmd or a command cmd runs on another host, and
+// describes any violation in terms of cmd.
+func (p *Policy) check(cmd, c *Cmd, remote bool) error {
+	violation := func(rule, format string, a ...interface{}) error {
+		return &PolicyError{
+			Args:   cmd.RedactedArgs(),
+			Rule:   rule,
+			Reason: fmt.Sprintf(format, a...),
+		}
+	}
+	if 0 != len(p.Commands) {
+		for _, name := range programs(c, remote) {
+			if !contains(p.Commands, name) {
+				return violation("commands", "%s isn't allowed", name)
+			}
+		}
+	}
+	for _, v := range c.values {
+		if _, ok := v.(Escalator); !ok || 0 == len(p.SudoUsers) {
+			continue
+		}
+		user := "root"
+		if f := reflect.Indirect(reflect.ValueOf(v)).FieldByName("User"); f.IsValid() && "" != f.String() {
+			user = f.String()
+		}
+		if !contains(p.SudoUsers, user) {
+			return violation("sudo_users", "running as %s isn't allowed", user)
+		}
+	}
+	for _, rule := range p.Forbid {
+		if "" != rule.Arg {
+			for _, arg := range c.Args {
+				if ok, _ := path.Match(rule.Arg, arg); ok {
+					return violation(
+						rule.Name,
+						"argument %s is forbidden",
+						QuoteArg(cmd.Redact(arg)),
+					)
+				}
+			}
+		}
+		if "" != rule.Field {
+			for _, v := range c.values {
+				if rule.matches(v) {
+					return violation(rule.Name, "%s is forbidden", rule)
+				}
+			}
+		}
+	}
+	if nil != c.remote {
+		return p.check(cmd, c.remote, true)
+	}
+	return nil
+}
+
+// PolicyError describes a command that breaks a Policy.
+type PolicyError struct {
+	Args   []string // the command and its arguments, redacted
+	Rule   string   // the rule's name or "commands" or "sudo_users"
+	Reason string
+}
+
+// Error returns the quoted command line, the rule's name, and why the command
+// breaks it.
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf(
+		"shellac: %s breaks policy rule %q: %s",
+		Quote(e.Args),
+		e.Rule,
+		e.Reason,
+	)
+}
+
+// PolicyRule forbids either an argument matching a pattern or a field being
+// set.
+type PolicyRule struct {
+	Name string `json:"name"`
+
+	// Arg is a pattern, as for path.Match, that no argument may match.
+	Arg string `json:"arg,omitempty"`
+
+	// Type is the struct type, as in "Find" or "coreutils.Find", whose Field
+	// must not be set, which is to say have a non-zero value.  If the field is
+	// a map, only Key must not be set.
+	Type  string `json:"type,omitempty"`
+	Field string `json:"field,omitempty"`
+	Key   string `json:"key,omitempty"`
+}
+
+// String returns the type, field, and key the rule forbids.
+func (rule PolicyRule) String() string {
+	s := rule.Type + "." + rule.Field
+	if "" != rule.Key {
+		s = fmt.Sprintf("%s[%s]", s, rule.Key)
+	}
+	return s
+}
+
+// matches returns true if v is a struct or pointer to a struct that has the
+// forbidden field set.
+func (rule PolicyRule) matches(v interface{}) bool {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if reflect.Struct != rv.Kind() {
+		return false
+	}
+	t := rv.Type()
+	if rule.Type != t.Name() && rule.Type != typeName(t) {
+		return false
+	}
+	f := rv.FieldByName(rule.Field)
+	if !f.IsValid() || f.IsZero() {
+		return false
+	}
+	if "" == rule.Key || reflect.Map != f.Kind() {
+		return true
+	}
+	for _, k := range f.MapKeys() {
+		if reflect.String == k.Kind() && strings.EqualFold(rule.Key, k.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+// validate returns an error if the rule is incomplete or its pattern is
+// malformed.
+func (rule PolicyRule) validate() error {
+	if "" == rule.Name {
+		return errors.New("shellac: policy rule has no name")
+	}
+	if "" == rule.Arg && ("" == rule.Type || "" == rule.Field) {
+		return fmt.Errorf(
+			"shellac: policy rule %q needs arg or type and field",
+			rule.Name,
+		)
+	}
+	if _, err := path.Match(rule.Arg, ""); nil != err {
+		return fmt.Errorf("shellac: policy rule %q: %v", rule.Name, err)
+	}
+	return nil
+}
+
+// contains returns true if s is in ss.
+func contains(ss []string, s string) bool {
+	for _, each := range ss {
+		if s == each {
+			return true
+		}
+	}
+	return false
+}
+
+// programs returns the names of every program the command runs, perhaps more
+// than once: the executable, each wrapper and escalator, as named and as
+// overridden by Binaries, and the command itself, as run by the innermost
+// wrapper or escalator.  Commands run on other hosts run their first argument
+// instead of their executable and don't consider Binaries.
+func programs(cmd *Cmd, remote bool) []string {
+	var names []string
+	if remote && 0 != len(cmd.Args) {
+		names = append(names, filepath.Base(cmd.Args[0]))
+	} else if !remote {
+		names = append(names, filepath.Base(cmd.Path))
+	}
+	for _, v := range cmd.values {
+		t := reflect.TypeOf(v)
+		if reflect.Ptr == t.Kind() {
+			t = t.Elem()
+		}
+		names = append(names, command(t))
+		if !remote {
+			names = append(names, filepath.Base(binary(t)))
+		}
+	}
+	n := len(cmd.sudo)
+	for _, wrapper := range cmd.wrappers {
+		n += len(wrapper)
+	}
+	if 0 != n && n < len(cmd.Args) {
+		names = append(names, filepath.Base(cmd.Args[n]))
+	}
+	return names
+}
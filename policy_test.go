@@ -0,0 +1,142 @@
+package shellac
+
+import (
+	"errors"
+	"github.com/rcrowley/go-shellac/coreutils"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadPolicy(t *testing.T) {
+	pathname := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(pathname, []byte(`{
+	"commands": ["find", "sudo"],
+	"sudo_users": ["postgres"],
+	"forbid": [
+		{"name": "no-delete", "type": "coreutils.Find", "field": "Delete"},
+		{"name": "no-exec", "type": "Find", "field": "Exec"}
+	]
+}`), 0666); nil != err {
+		t.Fatal(err)
+	}
+	p, err := LoadPolicy(pathname)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if 2 != len(p.Commands) || 1 != len(p.SudoUsers) || 2 != len(p.Forbid) {
+		t.Fatal(p)
+	}
+	testPolicyError(t, "no-exec", p.Check(Command(coreutils.Find{
+		Dirnames: []string{"."},
+		Exec:     coreutils.NewFindExec(coreutils.FindExecOne, "rm", "{}"),
+	})))
+}
+
+func TestLoadPolicyInvalid(t *testing.T) {
+	for _, s := range []string{
+		`{"forbid": [{"name": "no-type", "field": "Delete"}]}`,
+		`{"forbid": [{"name": "bad-pattern", "arg": "["}]}`,
+		`{"forbid": [{"type": "Find", "field": "Delete"}]}`,
+		`{"unknown": true}`,
+	} {
+		pathname := filepath.Join(t.TempDir(), "policy.json")
+		if err := os.WriteFile(pathname, []byte(s), 0666); nil != err {
+			t.Fatal(err)
+		}
+		if _, err := LoadPolicy(pathname); nil == err {
+			t.Fatal(s)
+		}
+	}
+}
+
+func TestPolicyArg(t *testing.T) {
+	p := &Policy{Forbid: []PolicyRule{{Name: "no-root", Arg: "/"}}}
+	if err := p.Check(toCmd(exec.Command("ls", "/tmp"))); nil != err {
+		t.Fatal(err)
+	}
+	testPolicyError(t, "no-root", p.Check(toCmd(exec.Command("ls", "/"))))
+}
+
+func TestPolicyCommands(t *testing.T) {
+	p := &Policy{Commands: []string{"find", "nice"}}
+	cmd := Command(coreutils.Find{Dirnames: []string{"."}})
+	if err := p.Check(cmd); nil != err {
+		t.Fatal(err)
+	}
+	cmd.Wrap(coreutils.Nice{})
+	if err := p.Check(cmd); nil != err {
+		t.Fatal(err)
+	}
+	cmd.Wrap(coreutils.Timeout{
+		Duration: coreutils.TimeoutDuration(time.Second),
+	})
+	testPolicyError(t, "commands", p.Check(cmd))
+	testPolicyError(t, "commands", p.Check(toCmd(exec.Command("/bin/rm"))))
+}
+
+func TestPolicyCommandsPath(t *testing.T) {
+	p := &Policy{Commands: []string{"find"}}
+	c := exec.Command("rm", "-rf", "/")
+	c.Args[0] = "find"
+	testPolicyError(t, "commands", p.Check(toCmd(c)))
+	defer func(binaries map[string]string) { Binaries = binaries }(Binaries)
+	Binaries = map[string]string{"find": "rm"}
+	testPolicyError(t, "commands", p.Check(Command(coreutils.Find{})))
+}
+
+func TestPolicyField(t *testing.T) {
+	p := &Policy{Forbid: []PolicyRule{
+		{Name: "no-delete", Type: "coreutils.Find", Field: "Delete"},
+	}}
+	if err := p.Check(Command(&coreutils.Find{})); nil != err {
+		t.Fatal(err)
+	}
+	cmd := Command(&coreutils.Find{Delete: true})
+	err := p.Check(cmd)
+	testPolicyError(t, "no-delete", err)
+	if !strings.Contains(err.Error(), "coreutils.Find.Delete is forbidden") {
+		t.Fatal(err)
+	}
+}
+
+func TestPolicyHook(t *testing.T) {
+	dirname := t.TempDir()
+	cmd := Command(coreutils.Find{Dirnames: []string{dirname}, Delete: true})
+	cmd.Hooks = []Hook{&Policy{Forbid: []PolicyRule{
+		{Name: "no-delete", Type: "Find", Field: "Delete"},
+	}}}
+	testPolicyError(t, "no-delete", cmd.Run())
+	if nil != cmd.Process {
+		t.Fatal(cmd.Process)
+	}
+	if _, err := os.Stat(dirname); nil != err {
+		t.Fatal(err)
+	}
+}
+
+func TestPolicySudoUsers(t *testing.T) {
+	p := &Policy{SudoUsers: []string{"postgres"}}
+	cmd := toCmd(exec.Command("true"))
+	cmd.Sudo(SudoOptions{User: "postgres"})
+	if err := p.Check(cmd); nil != err {
+		t.Fatal(err)
+	}
+	cmd = toCmd(exec.Command("true"))
+	cmd.Sudo()
+	testPolicyError(t, "sudo_users", p.Check(cmd))
+}
+
+func testPolicyError(t *testing.T, rule string, err error) {
+	t.Helper()
+	var e *PolicyError
+	if !errors.As(err, &e) {
+		t.Fatal(err)
+	}
+	if rule != e.Rule {
+		t.Fatal(e)
+	}
+}
@@ -0,0 +1,35 @@
+package shellac
+
+import "strings"
+
+// Quote returns the given arguments joined by spaces and quoted, as necessary,
+// so that a POSIX shell would parse them back into the same arguments.
+func Quote(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = QuoteArg(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// QuoteArg returns s quoted, if necessary, so that a POSIX shell would parse it
+// as a single argument.  Single quotes are used since nothing is special
+// within them except the single quote itself.
+func QuoteArg(s string) string {
+	if "" == s {
+		return "''"
+	}
+	if -1 == strings.IndexFunc(s, unsafe) {
+		return s
+	}
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// unsafe returns true if the rune r has special meaning to a POSIX shell.
+func unsafe(r rune) bool {
+	switch {
+	case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+		return false
+	}
+	return -1 == strings.IndexRune("%+,-./:=@_", r)
+}
@@ -0,0 +1,29 @@
+package shellac
+
+import "testing"
+
+func TestQuote(t *testing.T) {
+	s := Quote([]string{"find", ".", "-name", "*.go", "-printf", "%p\n"})
+	if "find . -name '*.go' -printf '%p\n'" != s {
+		t.Fatal(s)
+	}
+}
+
+func TestQuoteArg(t *testing.T) {
+	for arg, quoted := range map[string]string{
+		"":             "''",
+		"hi":           "hi",
+		"-perm=/644":   "-perm=/644",
+		"user@host:22": "user@host:22",
+		"foo bar":      "'foo bar'",
+		"$HOME":        "'$HOME'",
+		"it's":         `'it'\''s'`,
+		"{}":           "'{}'",
+		"a;b":          "'a;b'",
+		"~":            "'~'",
+	} {
+		if s := QuoteArg(arg); quoted != s {
+			t.Fatal(arg, s)
+		}
+	}
+}
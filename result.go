@@ -0,0 +1,84 @@
+package shellac
+
+import (
+	"fmt"
+	"time"
+)
+
+// Budget is how long a command may take and how much memory it may use before
+// a warning is logged.  Zero fields aren't checked.
+type Budget struct {
+	Duration time.Duration // wall time
+	MaxRSS   int64         // peak resident set size, in bytes
+}
+
+// check returns a description of each way the Result is over budget.
+func (b *Budget) check(r *Result) []string {
+	var over []string
+	if 0 < b.Duration && r.Duration > b.Duration {
+		over = append(over, fmt.Sprintf(
+			"took %v (budget %v)",
+			r.Duration.Round(time.Millisecond),
+			b.Duration,
+		))
+	}
+	if 0 < b.MaxRSS && r.MaxRSS > b.MaxRSS {
+		over = append(over, fmt.Sprintf(
+			"used %d bytes (budget %d bytes)",
+			r.MaxRSS,
+			b.MaxRSS,
+		))
+	}
+	return over
+}
+
+// Result describes how a command ran and what resources it used.  CPU times
+// and MaxRSS are zero if the command didn't run as a local process and MaxRSS
+// is only known on Linux.
+type Result struct {
+	Duration   time.Duration // wall time
+	UserTime   time.Duration // user CPU time
+	SystemTime time.Duration // system CPU time
+	MaxRSS     int64         // peak resident set size, in bytes
+	ExitCode   int           // as from Cmd.ExitCode
+	Err        error         // as from Run
+	OverBudget []string      // how the command exceeded its Budget, if it did
+}
+
+// RunResult logs and runs a shell command as described by the given interface
+// value, which may be a *Cmd or anything Run accepts, and describes how it
+// ran.
+func RunResult(i interface{}) (*Result, error) {
+	return toCmd(i).RunResult()
+}
+
+// Result returns a description of how the command ran or nil if it hasn't
+// exited.  It must only be called after Run, Wait, or Done return.
+func (cmd *Cmd) Result() *Result {
+	return cmd.result
+}
+
+// RunResult logs and runs a shell command like Run and describes how it ran.
+// The Result is non-nil even if the command couldn't be started.
+func (cmd *Cmd) RunResult() (*Result, error) {
+	err := cmd.Run()
+	return cmd.result, err
+}
+
+// newResult describes how the command ran, given the error from running it.
+func (cmd *Cmd) newResult(err error) *Result {
+	r := &Result{
+		Duration: time.Since(cmd.started),
+		ExitCode: exitCode(err),
+		Err:      err,
+	}
+	if ps := cmd.ProcessState; nil != ps {
+		r.UserTime = ps.UserTime()
+		r.SystemTime = ps.SystemTime()
+		r.MaxRSS = maxRSS(ps)
+	}
+	if nil != cmd.Budget {
+		r.OverBudget = cmd.Budget.check(r)
+	}
+	return r
+}
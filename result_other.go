@@ -0,0 +1,10 @@
+//go:build !linux
+
+package shellac
+
+import "os"
+
+// maxRSS returns 0 because the peak resident set size is only known on Linux.
+func maxRSS(ps *os.ProcessState) int64 {
+	return 0
+}
@@ -0,0 +1,59 @@
+package shellac
+
+import (
+	"bytes"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBudget(t *testing.T) {
+	var b bytes.Buffer
+	cmd := toCmd(exec.Command("sleep", "0.01"))
+	cmd.Budget = &Budget{Duration: time.Millisecond, MaxRSS: 1}
+	cmd.Logger = &TextLogger{W: &b}
+	r, err := cmd.RunResult()
+	if nil != err {
+		t.Fatal(err)
+	}
+	n := 1
+	if "linux" == runtime.GOOS {
+		n = 2
+	}
+	if n != len(r.OverBudget) || !strings.HasPrefix(r.OverBudget[0], "took ") {
+		t.Fatal(r.OverBudget)
+	}
+	expected := "sleep 0.01\nsleep 0.01: over budget: took "
+	if !strings.HasPrefix(b.String(), expected) {
+		t.Fatal(b.String())
+	}
+}
+
+func TestRunResult(t *testing.T) {
+	r, err := RunResult(exec.Command("sh", "-c", "exit 3"))
+	if e, ok := err.(*ExitError); !ok || 3 != e.ExitCode {
+		t.Fatal(err)
+	}
+	if 3 != r.ExitCode || err != r.Err || 0 == r.Duration {
+		t.Fatal(r)
+	}
+	if "linux" == runtime.GOOS && 0 == r.MaxRSS {
+		t.Fatal(r)
+	}
+	if nil != r.OverBudget {
+		t.Fatal(r.OverBudget)
+	}
+}
+
+func TestRunResultNotFound(t *testing.T) {
+	cmd := toCmd(exec.Command("shellac-not-found"))
+	if nil != cmd.Result() {
+		t.Fatal(cmd.Result())
+	}
+	r, err := cmd.RunResult()
+	if nil == err || nil == r || -1 != r.ExitCode || err != r.Err {
+		t.Fatal(r, err)
+	}
+}
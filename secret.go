@@ -0,0 +1,114 @@
+package shellac
+
+import (
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Redacted is what's logged in place of secrets.
+const Redacted = "***"
+
+// Redact returns s with every secret replaced by Redacted.
+func (cmd *Cmd) Redact(s string) string {
+	for _, secret := range cmd.secrets {
+		s = strings.Replace(s, secret, Redacted, -1)
+
+		// Secrets may also be found quoted as by QuoteArg, as in commands run
+		// remotely via ssh(1).
+		s = strings.Replace(
+			s,
+			strings.Replace(secret, "'", `'\''`, -1),
+			Redacted,
+			-1,
+		)
+	}
+	return s
+}
+
+// RedactedArgs returns the command and its arguments with every secret
+// replaced by Redacted.  This is what's logged and included in errors.
+func (cmd *Cmd) RedactedArgs() []string {
+	return cmd.redactAll(cmd.Args)
+}
+
+// Secret marks the given values as secret so they're never logged or included
+// in errors.  Values from fields tagged secret:"true" are marked as secret by
+// Command.
+func (cmd *Cmd) Secret(values ...string) {
+	for _, value := range values {
+		if "" != value {
+			cmd.secrets = append(cmd.secrets, value)
+		}
+	}
+
+	// Replace longer secrets first so that secrets which contain other
+	// secrets are redacted entirely.
+	sort.SliceStable(cmd.secrets, func(i, j int) bool {
+		return len(cmd.secrets[i]) > len(cmd.secrets[j])
+	})
+}
+
+// Secrets returns every value marked as secret, as when composing this command
+// into another.
+func (cmd *Cmd) Secrets() []string {
+	return append([]string{}, cmd.secrets...)
+}
+
+// SecretEnv sets an environment variable for the command and marks its value
+// as secret.  If the command's environment is nil, it starts as a copy of
+// this process' environment, as it would have been.
+func (cmd *Cmd) SecretEnv(key, value string) {
+	if nil == cmd.Env {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, key+"="+value)
+	cmd.Secret(value)
+}
+
+// SecretStdin connects standard input to s and marks s as secret.
+func (cmd *Cmd) SecretStdin(s string) {
+	cmd.Stdin = strings.NewReader(s)
+	cmd.Secret(s)
+}
+
+// redactAll returns a copy of args with every secret replaced by Redacted.
+func (cmd *Cmd) redactAll(args []string) []string {
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		redacted[i] = cmd.Redact(arg)
+	}
+	return redacted
+}
+
+// secrets returns the values of every field in the given interface value that
+// has the secret:"true" tag.
+func secrets(i interface{}) []string {
+	v := reflect.ValueOf(i)
+	if reflect.Ptr == v.Kind() {
+		v = v.Elem()
+	}
+	t := v.Type()
+	values := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if "true" != f.Tag.Get("secret") {
+			continue
+		}
+		flag, sep := f.Tag.Get("flag"), f.Tag.Get("sep")
+		if "-" == sep {
+			sep = ""
+		}
+		for _, arg := range field(f, v.Field(i)) {
+			if "" != flag && flag == arg {
+				continue
+			}
+			if "" != f.Tag.Get("sep") {
+				arg = strings.TrimPrefix(arg, flag+sep)
+			}
+			values = append(values, arg)
+		}
+	}
+	return values
+}
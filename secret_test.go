@@ -0,0 +1,85 @@
+package shellac
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSecret(t *testing.T) {
+	i := testSecret{User: "me", Password: "hunter2", Token: "s3cr3t"}
+	testArgs(t, []string{
+		"-u", "me", "-p", "hunter2", "--token=s3cr3t",
+	}, Args(i))
+	cmd := Command(i)
+	testArgs(t, []string{
+		"sh", "-u", "me", "-p", "***", "--token=***",
+	}, cmd.RedactedArgs())
+	if "sh -u me -p '***' '--token=***'" != cmd.String() {
+		t.Fatal(cmd)
+	}
+}
+
+func TestSecretExitError(t *testing.T) {
+	cmd := Command(testSecret{Password: "hunter2", Script: []string{"exit 1"}})
+	err := cmd.Run()
+	var e *ExitError
+	if !errors.As(err, &e) || strings.Contains(e.Error(), "hunter2") {
+		t.Fatal(err)
+	}
+	testArgs(t, []string{"sh", "-p", "***", "-c", "exit 1"}, e.Args)
+}
+
+func TestSecretLog(t *testing.T) {
+	var b bytes.Buffer
+	cmd := Command(testSecret{Password: "hunter2"})
+	cmd.Logger = NewTextLogger(&b)
+	cmd.Log()
+	if "sh -p '***'\n" != b.String() {
+		t.Fatal(b.String())
+	}
+}
+
+func TestSecretEnv(t *testing.T) {
+	cmd := Command(testSecret{Script: []string{`test "$TOKEN" = hunter2`}})
+	cmd.SecretEnv("TOKEN", "hunter2")
+	if len(os.Environ())+1 != len(cmd.Env) {
+		t.Fatal(cmd.Env)
+	}
+	if err := cmd.Run(); nil != err {
+		t.Fatal(err)
+	}
+	if "TOKEN=***" != cmd.Redact(cmd.Env[len(cmd.Env)-1]) {
+		t.Fatal(cmd.Env)
+	}
+}
+
+func TestSecretStdin(t *testing.T) {
+	cmd := Command(testSecret{Script: []string{"cat"}})
+	cmd.SecretStdin("hunter2")
+	p, err := cmd.Output()
+	if nil != err {
+		t.Fatal(err)
+	}
+	if "hunter2" != string(p) || "***" != cmd.Redact(string(p)) {
+		t.Fatal(string(p))
+	}
+}
+
+func TestSecretQuoted(t *testing.T) {
+	cmd := Command(testSecret{})
+	cmd.Secret("it's", "it's a secret")
+	if s := cmd.Redact(Quote([]string{"-p", "it's a secret"})); "-p '***'" != s {
+		t.Fatal(s)
+	}
+}
+
+type testSecret struct {
+	_        struct{} `command:"sh"`
+	User     string   `flag:"-u"`
+	Password string   `flag:"-p" secret:"true"`
+	Token    string   `flag:"--token" sep:"=" secret:"true"`
+	Script   []string `flag:"-c"`
+}
@@ -3,11 +3,17 @@
 package shellac
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"iter"
 	"os"
 	"os/exec"
 	"reflect"
 	"strings"
+	"time"
 )
 
 // Args returns a slice of strings of the arguments to the command described by
@@ -32,19 +38,51 @@ import (
 // the flag and the field value.  By default they're separated by a single
 // space; the tag "-" removes the separator; any other value is used literally.
 //
+// Fields with the tag secret:"true" are included as usual but are redacted
+// wherever the command is logged or included in an error.
+//
+// Fields with since or until tags are only supported by versions of the command
+// at least since and older than until, as checked by CheckVersion.
+//
+// Fields with a dialect tag are spelled differently or not supported at all by
+// some implementations of the command, as described by Dialect.
+//
 // See <https://github.com/rcrowley/go-shellac/blob/master/shellac_test.go> for
 // examples.
 func Args(i interface{}) []string {
+	args, _ := ArgsDialect(i, "")
+	return args
+}
+
+// ArgsDialect returns a slice of strings of the arguments to the command
+// described by the given interface value, as for Args, but with each flag
+// spelled as in the given Dialect.  If a field that's set has no equivalent in
+// the Dialect, the error is a *DialectError.
+func ArgsDialect(i interface{}, d Dialect) ([]string, error) {
 	v := reflect.ValueOf(i)
 	if reflect.Ptr == v.Kind() {
 		v = v.Elem()
 	}
 	t := v.Type()
 	fields := make([]string, 0, t.NumField())
+	var err error
+	add := func(i int) {
+		f, ok := inDialect(t.Field(i), d)
+		args := field(f, v.Field(i))
+		if ok {
+			fields = append(fields, args...)
+		} else if 0 != len(args) && nil == err {
+			err = &DialectError{
+				Command: command(t),
+				Dialect: d,
+				Flag:    flagName(f),
+			}
+		}
+	}
 	for i := 0; i < t.NumField(); i++ {
 		tag := t.Field(i).Tag
 		if pos := tag.Get("pos"); "first" == pos {
-			fields = append(fields, field(t.Field(i), v.Field(i))...)
+			add(i)
 		}
 	}
 	for i := 0; i < t.NumField(); i++ {
@@ -57,38 +95,108 @@ func Args(i interface{}) []string {
 			continue
 		}
 		if pos := tag.Get("pos"); "first" != pos && "last" != pos {
-			fields = append(fields, field(t.Field(i), v.Field(i))...)
+			add(i)
 		}
 	}
 	for i := 0; i < t.NumField(); i++ {
 		tag := t.Field(i).Tag
 		if pos := tag.Get("pos"); "last" == pos {
-			fields = append(fields, field(t.Field(i), v.Field(i))...)
+			add(i)
 		}
 	}
-	return fields
+	if nil != err {
+		return nil, err
+	}
+	return fields, nil
 }
 
 // Cmd wraps exec.Cmd to add convenience methods.
 type Cmd struct {
 	exec.Cmd
+
+	// Executor runs the command.  If nil, DefaultExecutor is used.
+	Executor Executor
+
+	// ErrorFunc, if non-nil, may replace any error from running the command,
+	// as ssh.Remote does to tell ssh(1)'s own errors apart.
+	ErrorFunc func(error) error
+
+	// Logger logs the command.  If nil, DefaultLogger is used.
+	Logger Logger
+
+	// Budget, if non-nil, causes a warning to be logged if the command takes
+	// too long or uses too much memory.
+	Budget *Budget
+
+	// Hooks are called as the command starts and exits, after the global
+	// Hooks.
+	Hooks []Hook
+
+	dialect   *Dialect // if non-nil, as from CommandDialect
+	done      chan struct{}
+	err       error
+	exitFuncs []func(error)
+	hooks     []Hook
+	process   Process
+	remote    *Cmd
+	result    *Result
+	secrets   []string
+	started   time.Time
+	sudo      []string
+	values    []interface{}
+	wrappers  [][]string
 }
 
 // Command returns a *Cmd (with standard input, output, and error connected)
 // as described by the given interface value, which should be a struct or
-// pointer to a struct.
+// pointer to a struct.  The executable is found as by Which and its arguments
+// are in its Dialect.  If it isn't found, if, per CheckVersion, the installed
+// version doesn't support a flag that's set, or if its Dialect has no
+// equivalent for one, the error is kept in cmd.Err, as exec.Command does, so
+// that Start fails.  The executable isn't found, its version isn't checked,
+// and Dialects not in Dialects aren't detected unless DefaultExecutor is a
+// LocalExecutor.
 func Command(i interface{}) *Cmd {
+	path, args, err := resolve(i, DefaultExecutor)
+	return newCmd(i, path, args, err)
+}
+
+// CommandDialect returns a *Cmd as Command does but without finding its
+// executable or running it, as for commands that run on other hosts, as by
+// ssh.Remote.  Its arguments and those of any wrappers added by Wrap or Sudo
+// are in the given Dialect, as by ArgsDialect.  If the Dialect has no
+// equivalent for a flag that's set, the error is kept in cmd.Err so that Start
+// fails.
+func CommandDialect(i interface{}, d Dialect) *Cmd {
 	t := reflect.TypeOf(i)
 	if reflect.Ptr == t.Kind() {
 		t = t.Elem()
 	}
-	cmd := &Cmd{*exec.Command(command(t), Args(i)...)}
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	args, err := ArgsDialect(i, d)
+	if nil != err {
+		args = Args(i)
+	}
+	cmd := newCmd(i, command(t), args, err)
+	cmd.dialect = &d
 	return cmd
 }
 
+// ChangedEnv returns the variables in the command's environment that aren't in
+// this process' environment with the same values, as SecretEnv may copy it.
+func (cmd *Cmd) ChangedEnv() []string {
+	local := make(map[string]bool)
+	for _, kv := range os.Environ() {
+		local[kv] = true
+	}
+	var changed []string
+	for _, kv := range cmd.Env {
+		if !local[kv] {
+			changed = append(changed, kv)
+		}
+	}
+	return changed
+}
+
 // ChannelStdin connects standard input to a channel.
 func (cmd *Cmd) ChannelStdin(stdin <-chan string) {
 	cmd.Stdin = NewChanReader(stdin)
@@ -96,66 +204,318 @@ func (cmd *Cmd) ChannelStdin(stdin <-chan string) {
 
 // ChannelStdout connects standard output to a channel.
 func (cmd *Cmd) ChannelStdout(stdout chan<- string) {
-	cmd.Stdout = NewChanWriter(stdout)
+	cmd.Stdout = addWriter(cmd.Stdout, os.Stdout, NewChanWriter(stdout))
 }
 
 // ChannelStderr connects standard errput to a channel.
 func (cmd *Cmd) ChannelStderr(stderr chan<- string) {
-	cmd.Stderr = NewChanWriter(stderr)
+	cmd.Stderr = addWriter(cmd.Stderr, os.Stderr, NewChanWriter(stderr))
 }
 
-// Log logs the command (bolded if standard error is a TTY) to standard error.
-// This is sort of like what make(1) or sh(1) with -x do.
+// ChannelStdinBytes connects standard input to a channel of raw chunks of
+// bytes.
+func (cmd *Cmd) ChannelStdinBytes(stdin <-chan []byte) {
+	cmd.Stdin = NewByteChanReader(stdin)
+}
+
+// ChannelStdoutBytes connects standard output to a channel of raw chunks of
+// bytes, which is safe for binary output.
+func (cmd *Cmd) ChannelStdoutBytes(stdout chan<- []byte) {
+	cmd.Stdout = addWriter(cmd.Stdout, os.Stdout, NewByteChanWriter(stdout))
+}
+
+// ChannelStderrBytes connects standard error to a channel of raw chunks of
+// bytes, which is safe for binary output.
+func (cmd *Cmd) ChannelStderrBytes(stderr chan<- []byte) {
+	cmd.Stderr = addWriter(cmd.Stderr, os.Stderr, NewByteChanWriter(stderr))
+}
+
+// ChannelStdinDelim connects standard input to a channel of lines which will
+// be delimited by delim.
+func (cmd *Cmd) ChannelStdinDelim(stdin <-chan string, delim byte) {
+	cmd.Stdin = NewChanReaderDelim(stdin, delim)
+}
+
+// ChannelStdoutDelim connects standard output to a channel of lines delimited
+// by delim, as in cmd.ChannelStdoutDelim(ch, 0) for find -print0.
+func (cmd *Cmd) ChannelStdoutDelim(stdout chan<- string, delim byte) {
+	cmd.Stdout = addWriter(
+		cmd.Stdout,
+		os.Stdout,
+		NewChanWriterDelim(stdout, delim),
+	)
+}
+
+// ChannelStderrDelim connects standard error to a channel of lines delimited
+// by delim.
+func (cmd *Cmd) ChannelStderrDelim(stderr chan<- string, delim byte) {
+	cmd.Stderr = addWriter(
+		cmd.Stderr,
+		os.Stderr,
+		NewChanWriterDelim(stderr, delim),
+	)
+}
+
+// CombinedOutput logs and runs a shell command and returns its combined
+// standard output and standard error.  Standard output and error must not
+// have been connected to anything other than their defaults.
+func (cmd *Cmd) CombinedOutput() ([]byte, error) {
+	if !isDefault(cmd.Stdout, os.Stdout) {
+		return nil, cmd.exit(errors.New("shellac: Stdout already set"))
+	}
+	if !isDefault(cmd.Stderr, os.Stderr) {
+		return nil, cmd.exit(errors.New("shellac: Stderr already set"))
+	}
+	var b bytes.Buffer
+	cmd.Stdout = &b
+	cmd.Stderr = &b
+	err := cmd.Run()
+	return b.Bytes(), err
+}
+
+// Done returns a channel that's closed when the command started by Start,
+// Run, or Output exits and standard output and error have been closed.  It
+// returns nil if the command hasn't been started.
+func (cmd *Cmd) Done() <-chan struct{} {
+	return cmd.done
+}
+
+// ExitCode returns the exit code of the command or -1 if it hasn't exited,
+// couldn't be started, or was killed by a signal.
+func (cmd *Cmd) ExitCode() int {
+	select {
+	case <-cmd.done:
+	default:
+		return -1
+	}
+	return cmd.result.ExitCode
+}
+
+// Lines logs and runs a shell command and returns its standard output split
+// into lines, without their trailing newlines.
+func (cmd *Cmd) Lines() ([]string, error) {
+	p, err := cmd.Output()
+	if 0 == len(p) {
+		return []string{}, err
+	}
+	return strings.Split(strings.TrimSuffix(string(p), "\n"), "\n"), err
+}
+
+// LinesSeq logs and starts a shell command and returns an iterator over the
+// lines of its standard output, without their trailing newlines, alongside
+// any channels, callbacks, or tees already connected.  If the command fails,
+// the error is yielded last with an empty line.  Breaking out of the loop
+// early kills the command and waits for it to exit.
+func (cmd *Cmd) LinesSeq() iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		ch := make(chan string)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		w := NewChanWriter(ch)
+		w.SetContext(ctx)
+		cmd.Stdout = addWriter(cmd.Stdout, os.Stdout, w)
+		if err := cmd.Start(); nil != err {
+			yield("", err)
+			return
+		}
+		for s := range ch {
+			if !yield(s, nil) {
+				cancel()
+				cmd.Signal(os.Kill)
+				cmd.Wait()
+				return
+			}
+		}
+		if err := cmd.Wait(); nil != err {
+			yield("", err)
+		}
+	}
+}
+
+// Log logs the command via its Logger or DefaultLogger.  By default, that's
+// to standard error (bolded if standard error is a TTY), which is sort of like
+// what make(1) or sh(1) with -x do.
 func (cmd *Cmd) Log() {
-	fi, err := os.Stderr.Stat()
-	if nil != err {
-		panic(err)
+	if l := cmd.logger(); nil != l {
+		l.LogStart(cmd)
 	}
-	var format string
-	if 0 == fi.Mode()&os.ModeCharDevice {
-		format = "%s\n"
-	} else {
-		format = "\033[1m%s\033[0m\n"
+}
+
+// Output logs and runs a shell command and returns its standard output.
+// Standard output must not have been connected to anything other than its
+// default.  Standard error is left alone and its tail is, as always, included
+// in any *ExitError.
+func (cmd *Cmd) Output() ([]byte, error) {
+	if !isDefault(cmd.Stdout, os.Stdout) {
+		return nil, cmd.exit(errors.New("shellac: Stdout already set"))
 	}
-	fmt.Fprintf(os.Stderr, format, strings.Join(cmd.Args, " "))
+	var b bytes.Buffer
+	cmd.Stdout = &b
+	err := cmd.Run()
+	return b.Bytes(), err
 }
 
-// Run logs and runs a shell command.
+// Run logs and runs a shell command.  If the command runs but does not exit
+// successfully, the error is an *ExitError, which includes the tail of
+// standard error even if standard error is also connected elsewhere.
 func (cmd *Cmd) Run() error {
-	cmd.Log()
-	defer cmd.closeStdoutStderr()
-	return cmd.Cmd.Run()
+	if err := cmd.Start(); nil != err {
+		return err
+	}
+	return cmd.Wait()
 }
 
-// Sudo modifies a shell command to be run as root via sudo(8).
-func (cmd *Cmd) Sudo() {
-	sudo, err := exec.LookPath("sudo")
+// SetRemote notes that the command runs inner on another host, as commands
+// from ssh.Remote do, so that a Policy checks inner, too.
+func (cmd *Cmd) SetRemote(inner *Cmd) {
+	cmd.remote = inner
+}
+
+// Signal sends sig to the command started by Start.
+func (cmd *Cmd) Signal(sig os.Signal) error {
+	if nil == cmd.process {
+		return errors.New("shellac: not started")
+	}
+	return cmd.process.Signal(sig)
+}
+
+// Start logs and starts a shell command but doesn't wait for it to exit.  Use
+// Wait or Done to find out when it does.  Unlike exec.Cmd.Start, channels
+// connected to standard output and error are closed when it does, without
+// having to call Wait.  If cmd.Err is set, Start fails with it whichever
+// Executor would have run the command.
+func (cmd *Cmd) Start() error {
+	cmd.begin()
+	if err := cmd.beforeStart(); nil != err {
+		return cmd.finish(err)
+	}
+	if nil != cmd.Err {
+		return cmd.finish(cmd.Err)
+	}
+	p, err := cmd.executor().Start(cmd)
 	if nil != err {
-		panic(err)
+		return cmd.finish(err)
+	}
+	cmd.process = p
+	if err := cmd.afterStart(p.Pid()); nil != err {
+		p.Signal(os.Kill)
+		p.Wait()
+		return cmd.finish(err)
 	}
-	args := make([]string, 1+len(cmd.Args))
-	args[0] = "sudo"
-	copy(args[1:], cmd.Args)
-	cmd.Args = args
-	cmd.Path = sudo
+	go func() { cmd.finish(p.Wait()) }()
+	return nil
 }
 
-// closeStdoutStderr calls Close on either or both of standard output and error
-// that is using a ChanWriter.
-func (cmd *Cmd) closeStdoutStderr() error {
-	if w, ok := cmd.Stdout.(*ChanWriter); ok {
-		if err := w.Close(); nil != err {
-			return err
-		}
+// Sudo modifies a shell command to be run as root via sudo(8) or, if any are
+// given, via each Escalator in turn.
+func (cmd *Cmd) Sudo(escalators ...Escalator) error {
+	if 0 == len(escalators) {
+		escalators = []Escalator{SudoOptions{}}
 	}
-	if w, ok := cmd.Stderr.(*ChanWriter); ok {
-		if err := w.Close(); nil != err {
+	for _, e := range escalators {
+		if err := e.Escalate(cmd); nil != err {
 			return err
 		}
 	}
 	return nil
 }
 
+// String returns the command line, quoted so that a POSIX shell would parse it
+// back into the same arguments, with every secret redacted.
+func (cmd *Cmd) String() string {
+	return Quote(cmd.RedactedArgs())
+}
+
+// Wait waits for the command started by Start to exit and returns the same
+// error Run would have.  It may be called more than once.
+func (cmd *Cmd) Wait() error {
+	if nil == cmd.done {
+		return errors.New("shellac: not started")
+	}
+	<-cmd.done
+	return cmd.err
+}
+
+// begin logs the command and notes that it's starting.
+func (cmd *Cmd) begin() {
+	cmd.Log()
+	cmd.done = make(chan struct{})
+	cmd.started = time.Now()
+}
+
+// closeStdoutStderr closes standard output and error as closeWriter would
+// and returns the first error.
+func (cmd *Cmd) closeStdoutStderr() error {
+	var err error
+	for _, w := range []io.Writer{cmd.Stdout, cmd.Stderr} {
+		if e := closeWriter(w); nil != e && nil == err {
+			err = e
+		}
+	}
+	return err
+}
+
+// executor returns the Executor that should run the command.
+func (cmd *Cmd) executor() Executor {
+	if nil != cmd.Executor {
+		return cmd.Executor
+	}
+	return DefaultExecutor
+}
+
+// finish logs the command's exit, closes standard output and error, calls
+// the AfterExit Hooks, and closes the channel returned by Done.  It returns
+// the error that Run and Wait return.
+func (cmd *Cmd) finish(err error) error {
+	err = cmd.mapError(err)
+	cmd.result = cmd.newResult(err)
+	cmd.logExit(cmd.result.Duration, err)
+	cmd.err = cmd.exit(err)
+	cmd.result.Err = cmd.err
+	if err := cmd.afterExit(cmd.result); nil != err && nil == cmd.err {
+		cmd.err = err
+	}
+	close(cmd.done)
+	return cmd.err
+}
+
+// logExit logs the command's exit via its Logger or DefaultLogger.
+func (cmd *Cmd) logExit(d time.Duration, err error) {
+	if l := cmd.logger(); nil != l {
+		l.LogExit(cmd, d, err)
+	}
+}
+
+// logger returns the Logger that should log the command or nil.
+func (cmd *Cmd) logger() Logger {
+	if nil != cmd.Logger {
+		return cmd.Logger
+	}
+	return DefaultLogger
+}
+
+// mapError passes a non-nil err through ErrorFunc, if it's set.
+func (cmd *Cmd) mapError(err error) error {
+	if nil == err || nil == cmd.ErrorFunc {
+		return err
+	}
+	return cmd.ErrorFunc(err)
+}
+
+// runContext logs and runs a shell command like Run but kills it if ctx is
+// done before it exits.
+func (cmd *Cmd) runContext(ctx context.Context) error {
+	if err := cmd.Start(); nil != err {
+		return err
+	}
+	select {
+	case <-cmd.Done():
+	case <-ctx.Done():
+		cmd.Signal(os.Kill)
+	}
+	return cmd.Wait()
+}
+
 // NewInt returns a pointer to the given integer.
 func NewInt(i int) *int {
 	return &i
@@ -166,34 +526,26 @@ func NewString(s string) *string {
 	return &s
 }
 
+// Output constructs and runs a shell command from the given interface value or
+// simply runs a Cmd or exec.Cmd and returns its standard output.
+func Output(i interface{}) ([]byte, error) {
+	return toCmd(i).Output()
+}
+
 // Run constructs and runs a shell command from the given interface value or
 // simply runs a Cmd or exec.Cmd.
 func Run(i interface{}) error {
-	if cmd, ok := i.(*Cmd); ok {
-		return cmd.Run()
-	}
-	if execCmd, ok := i.(*exec.Cmd); ok {
-		cmd := &Cmd{*execCmd}
-		return cmd.Run()
-	}
-	return Command(i).Run()
+	return toCmd(i).Run()
 }
 
 // Sudo constructs and runs a shell command from the given interface value or
 // simply runs a Cmd or exec.Cmd.  In any case, the command is run as root via
-// sudo(8).
-func Sudo(i interface{}) error {
-	if cmd, ok := i.(*Cmd); ok {
-		cmd.Sudo()
-		return cmd.Run()
-	}
-	if execCmd, ok := i.(*exec.Cmd); ok {
-		cmd := &Cmd{*execCmd}
-		cmd.Sudo()
-		return cmd.Run()
+// sudo(8) or, if any are given, via each Escalator in turn.
+func Sudo(i interface{}, escalators ...Escalator) error {
+	cmd := toCmd(i)
+	if err := cmd.Sudo(escalators...); nil != err {
+		return err
 	}
-	cmd := Command(i)
-	cmd.Sudo()
 	return cmd.Run()
 }
 
@@ -269,3 +621,64 @@ func field(f reflect.StructField, v reflect.Value) []string {
 		return []string{fmt.Sprintf("%s%s%s", flag, sep, arg)}
 	}
 }
+
+// flagName returns the field's flag or, if it has none, its name.
+func flagName(f reflect.StructField) string {
+	if flag := f.Tag.Get("flag"); "" != flag && "-" != flag {
+		return flag
+	}
+	return f.Name
+}
+
+// isDefault returns true if w is nil or the default writer def.
+func isDefault(w io.Writer, def *os.File) bool {
+	if nil == w {
+		return true
+	}
+	f, ok := w.(*os.File)
+	return ok && def == f
+}
+
+// isSameWriter returns true if w1 and w2 are the same writer.  Writers whose
+// dynamic types aren't comparable are never the same.
+func isSameWriter(w1, w2 io.Writer) (same bool) {
+	defer func() {
+		if nil != recover() {
+			same = false
+		}
+	}()
+	return w1 == w2
+}
+
+// newCmd returns a *Cmd (with standard input, output, and error connected)
+// that runs the given executable with the given arguments as described by the
+// given interface value.  A non-nil err is kept in cmd.Err.
+func newCmd(i interface{}, path string, args []string, err error) *Cmd {
+	t := reflect.TypeOf(i)
+	if reflect.Ptr == t.Kind() {
+		t = t.Elem()
+	}
+	cmd := &Cmd{Cmd: exec.Cmd{
+		Path: path,
+		Args: append([]string{command(t)}, args...),
+		Err:  err,
+	}}
+	cmd.values = []interface{}{i}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Secret(secrets(i)...)
+	return cmd
+}
+
+// toCmd returns the *Cmd described by the given interface value, which may be
+// a *Cmd, an *exec.Cmd, or anything Command accepts.
+func toCmd(i interface{}) *Cmd {
+	if cmd, ok := i.(*Cmd); ok {
+		return cmd
+	}
+	if execCmd, ok := i.(*exec.Cmd); ok {
+		return &Cmd{Cmd: *execCmd}
+	}
+	return Command(i)
+}
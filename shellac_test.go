@@ -1,6 +1,12 @@
 package shellac
 
-import "testing"
+import (
+	"errors"
+	"github.com/rcrowley/go-shellac/coreutils"
+	"os"
+	"os/exec"
+	"testing"
+)
 
 func TestArgs(t *testing.T) {
 	testArgs(t, []string{}, Args(test{}))
@@ -143,6 +149,185 @@ func TestCommandDefault(t *testing.T) {
 	}
 }
 
+func TestCombinedOutput(t *testing.T) {
+	cmd := toCmd(exec.Command("sh", "-c", "echo out; echo err >&2"))
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	p, err := cmd.CombinedOutput()
+	if nil != err {
+		t.Fatal(err)
+	}
+	if "out\nerr\n" != string(p) {
+		t.Fatal(string(p))
+	}
+}
+
+func TestCombinedOutputStdoutSet(t *testing.T) {
+	cmd := Command(test{})
+	ch := make(chan string)
+	cmd.ChannelStdout(ch)
+	if _, err := cmd.CombinedOutput(); nil == err {
+		t.Fatal(err)
+	}
+	if _, ok := <-ch; ok {
+		t.Fatal(ok)
+	}
+}
+
+func TestLines(t *testing.T) {
+	lines, err := toCmd(exec.Command("printf", "foo\nbar\n")).Lines()
+	if nil != err {
+		t.Fatal(err)
+	}
+	testArgs(t, []string{"foo", "bar"}, lines)
+	lines, err = toCmd(exec.Command("true")).Lines()
+	if nil != err {
+		t.Fatal(err)
+	}
+	testArgs(t, []string{}, lines)
+}
+
+func TestLinesSeq(t *testing.T) {
+	var lines []string
+	for line, err := range toCmd(exec.Command("printf", "foo\nbar")).LinesSeq() {
+		if nil != err {
+			t.Fatal(err)
+		}
+		lines = append(lines, line)
+	}
+	testArgs(t, []string{"foo", "bar"}, lines)
+}
+
+func TestLinesSeqBreak(t *testing.T) {
+	cmd := toCmd(exec.Command("yes"))
+	n := 0
+	for line, err := range cmd.LinesSeq() {
+		if nil != err {
+			t.Fatal(err)
+		}
+		if "y" != line {
+			t.Fatal(line)
+		}
+		if n++; 3 == n {
+			break
+		}
+	}
+	if nil == cmd.ProcessState || cmd.ProcessState.Success() {
+		t.Fatal(cmd.ProcessState)
+	}
+}
+
+func TestLinesSeqExitError(t *testing.T) {
+	cmd := toCmd(exec.Command("sh", "-c", "echo foo; exit 3"))
+	var lines []string
+	var err error
+	for line, e := range cmd.LinesSeq() {
+		lines = append(lines, line)
+		err = e
+	}
+	testArgs(t, []string{"foo", ""}, lines)
+	if e, ok := err.(*ExitError); !ok || 3 != e.ExitCode {
+		t.Fatal(err)
+	}
+}
+
+func TestOutput(t *testing.T) {
+	p, err := Output(coreutils.Find{
+		Dirnames: []string{"."},
+		Name:     "shellac.go",
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+	if "./shellac.go\n" != string(p) {
+		t.Fatal(string(p))
+	}
+}
+
+func TestOutputExitError(t *testing.T) {
+	p, err := Output(exec.Command("sh", "-c", "echo out; echo err >&2; false"))
+	if "out\n" != string(p) {
+		t.Fatal(string(p))
+	}
+	if e, ok := err.(*ExitError); !ok || "err\n" != string(e.Stderr) {
+		t.Fatal(err)
+	}
+}
+
+func TestOutputStdoutSet(t *testing.T) {
+	cmd := Command(test{})
+	cmd.ChannelStdout(make(chan string))
+	if _, err := cmd.Output(); nil == err {
+		t.Fatal(err)
+	}
+}
+
+func TestSignal(t *testing.T) {
+	cmd := toCmd(exec.Command("sleep", "10"))
+	if err := cmd.Signal(os.Kill); nil == err {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); nil != err {
+		t.Fatal(err)
+	}
+	if -1 != cmd.ExitCode() {
+		t.Fatal(cmd.ExitCode())
+	}
+	if err := cmd.Signal(os.Kill); nil != err {
+		t.Fatal(err)
+	}
+	<-cmd.Done()
+	var e *ExitError
+	if err := cmd.Wait(); !errors.As(err, &e) || os.Kill != e.Signal {
+		t.Fatal(err)
+	}
+	if -1 != cmd.ExitCode() {
+		t.Fatal(cmd.ExitCode())
+	}
+}
+
+func TestStart(t *testing.T) {
+	cmd := toCmd(exec.Command("sh", "-c", "echo foo; echo bar; exit 3"))
+	ch := make(chan string)
+	cmd.ChannelStdout(ch)
+	if nil != cmd.Done() {
+		t.Fatal(cmd.Done())
+	}
+	if err := cmd.Start(); nil != err {
+		t.Fatal(err)
+	}
+	var lines []string
+	for line := range ch {
+		lines = append(lines, line)
+	}
+	testArgs(t, []string{"foo", "bar"}, lines)
+	<-cmd.Done()
+	if 3 != cmd.ExitCode() {
+		t.Fatal(cmd.ExitCode())
+	}
+	for i := 0; i < 2; i++ {
+		if e, ok := cmd.Wait().(*ExitError); !ok || 3 != e.ExitCode {
+			t.Fatal(e)
+		}
+	}
+}
+
+func TestStartNotFound(t *testing.T) {
+	cmd := toCmd(exec.Command("shellac-not-found"))
+	if err := cmd.Start(); nil == err {
+		t.Fatal(err)
+	}
+	<-cmd.Done()
+	if -1 != cmd.ExitCode() {
+		t.Fatal(cmd.ExitCode())
+	}
+}
+
+func TestStartWaitNotStarted(t *testing.T) {
+	if err := toCmd(exec.Command("true")).Wait(); nil == err {
+		t.Fatal(err)
+	}
+}
+
 func TestSudoCommand(t *testing.T) {
 	cmd := Command(test{})
 	cmd.Sudo()
@@ -154,6 +339,40 @@ func TestSudoCommand(t *testing.T) {
 	}
 }
 
+func TestSudoCommandDoas(t *testing.T) {
+	t.Setenv("PATH", "")
+	cmd := Command(test{})
+	if err := cmd.Sudo(DoasOptions{}); nil == err {
+		t.Fatal(cmd)
+	}
+	testArgs(t, []string{"doas", "test"}, cmd.Args)
+	if err := cmd.Run(); nil == err || cmd.Err != err {
+		t.Fatal(err)
+	}
+}
+
+func TestSudoCommandOptions(t *testing.T) {
+	cmd := Command(test{})
+	if err := cmd.Sudo(SudoOptions{
+		NonInteractive:  true,
+		PreserveEnvList: "PATH,HOME",
+		User:            "postgres",
+	}); nil != err {
+		t.Fatal(err)
+	}
+	testArgs(t, []string{
+		"sudo", "--preserve-env=PATH,HOME", "-n", "-u", "postgres", "test",
+	}, cmd.Args)
+}
+
+func TestSudoCommandRunuser(t *testing.T) {
+	cmd := Command(test{})
+	if err := cmd.Sudo(RunuserOptions{User: "nobody"}); nil != err {
+		t.Skip(err)
+	}
+	testArgs(t, []string{"runuser", "-u", "nobody", "test"}, cmd.Args)
+}
+
 type test struct {
 	_              struct{}  `command:"test"`
 	Flag           string    `flag:"-flag"`
@@ -0,0 +1,2 @@
+// Shellac executors and assertions for testing code that runs commands.
+package shellactest
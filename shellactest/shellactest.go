@@ -0,0 +1,295 @@
+package shellactest
+
+import (
+	"fmt"
+	"github.com/rcrowley/go-shellac"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// UpdateEnv is the environment variable that, when set to a non-empty value,
+// causes golden assertions to rewrite their golden files instead of
+// comparing against them.
+const UpdateEnv = "SHELLACTEST_UPDATE"
+
+// AssertArgs fails the test with a diff if actual isn't the same as expected.
+func AssertArgs(t testing.TB, expected, actual []string) {
+	t.Helper()
+	if !equal(expected, actual) {
+		t.Fatalf("arguments differ:\n%s", diff(
+			[]string{shellac.Quote(expected)},
+			[]string{shellac.Quote(actual)},
+		))
+	}
+}
+
+// AssertArgsGolden fails the test with a diff if actual isn't the same as the
+// arguments in testdata/<name>.golden.
+func AssertArgsGolden(t testing.TB, name string, actual []string) {
+	t.Helper()
+	golden(t, name, []string{shellac.Quote(actual)})
+}
+
+// Install constructs a Recorder and makes it shellac.DefaultExecutor for the
+// remainder of the test.
+func Install(t testing.TB) *Recorder {
+	r := NewRecorder()
+	e := shellac.DefaultExecutor
+	shellac.DefaultExecutor = r
+	t.Cleanup(func() { shellac.DefaultExecutor = e })
+	return r
+}
+
+// Recorder is a shellac.Executor that doesn't run anything.  It records every
+// command and responds with the first scripted response whose pattern matches
+// the command's arguments.
+//
+// A pattern is a list of arguments.  Within each, * matches any sequence of
+// characters and ? matches any one character.  A final ... matches any
+// number of remaining arguments.
+type Recorder struct {
+
+	// Strict causes commands that match no pattern to fail.  Otherwise they
+	// succeed with no output.
+	Strict bool
+
+	calls [][]string
+	mu    sync.Mutex
+	rules []rule
+}
+
+// NewRecorder constructs an empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// AssertCalled fails the test if no command matching pattern was run.
+func (r *Recorder) AssertCalled(t testing.TB, pattern ...string) {
+	t.Helper()
+	for _, call := range r.Calls() {
+		if match(pattern, call) {
+			return
+		}
+	}
+	t.Fatalf(
+		"no command matching %s among:\n%s",
+		shellac.Quote(pattern),
+		strings.Join(quoteAll(r.Calls()), "\n"),
+	)
+}
+
+// AssertCalls fails the test with a diff if the commands run weren't exactly
+// the expected commands in the expected order.
+func (r *Recorder) AssertCalls(t testing.TB, expected ...[]string) {
+	t.Helper()
+	e, a := quoteAll(expected), quoteAll(r.Calls())
+	if !equal(e, a) {
+		t.Fatalf("commands differ:\n%s", diff(e, a))
+	}
+}
+
+// AssertCount fails the test if n commands weren't run.
+func (r *Recorder) AssertCount(t testing.TB, n int) {
+	t.Helper()
+	if calls := r.Calls(); n != len(calls) {
+		t.Fatalf(
+			"expected %d commands, got %d:\n%s",
+			n,
+			len(calls),
+			strings.Join(quoteAll(calls), "\n"),
+		)
+	}
+}
+
+// AssertGolden fails the test with a diff if the commands run weren't the
+// same as the commands in testdata/<name>.golden.
+func (r *Recorder) AssertGolden(t testing.TB, name string) {
+	t.Helper()
+	golden(t, name, quoteAll(r.Calls()))
+}
+
+// Calls returns the arguments of every command run, in order.
+func (r *Recorder) Calls() [][]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([][]string{}, r.calls...)
+}
+
+// On scripts the response to commands whose arguments match pattern.  Patterns
+// are tried in the order they were added.
+func (r *Recorder) On(
+	response shellac.FakeResponse,
+	pattern ...string,
+) *Recorder {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rules = append(r.rules, rule{pattern, response})
+	return r
+}
+
+// Start records the command and returns a Process that writes the standard
+// output and error of its response.
+func (r *Recorder) Start(cmd *shellac.Cmd) (shellac.Process, error) {
+	e, err := r.executor(cmd)
+	if nil != err {
+		return nil, err
+	}
+	return e.Start(cmd)
+}
+
+// executor records the command and returns a shellac.FakeExecutor scripted
+// with its response.
+func (r *Recorder) executor(cmd *shellac.Cmd) (shellac.Executor, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, append([]string{}, cmd.Args...))
+	for _, rule := range r.rules {
+		if match(rule.pattern, cmd.Args) {
+			return shellac.NewFakeExecutor(rule.response), nil
+		}
+	}
+	if r.Strict {
+		return nil, fmt.Errorf(
+			"shellactest: no response for %s",
+			shellac.Quote(cmd.Args),
+		)
+	}
+	return shellac.NewFakeExecutor(shellac.FakeResponse{}), nil
+}
+
+type rule struct {
+	pattern  []string
+	response shellac.FakeResponse
+}
+
+// diff returns a line-by-line diff from expected to actual with removed lines
+// prefixed by - and added lines prefixed by +.
+func diff(expected, actual []string) string {
+	m, n := len(expected), len(actual)
+	lcs := make([][]int, m+1)
+	for i := range lcs {
+		lcs[i] = make([]int, n+1)
+	}
+	for i := m - 1; i >= 0; i-- {
+		for j := n - 1; j >= 0; j-- {
+			if expected[i] == actual[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	lines := []string{}
+	i, j := 0, 0
+	for i < m || j < n {
+		switch {
+		case i < m && j < n && expected[i] == actual[j]:
+			lines = append(lines, "  "+expected[i])
+			i++
+			j++
+		case j == n || i < m && lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, "- "+expected[i])
+			i++
+		default:
+			lines = append(lines, "+ "+actual[j])
+			j++
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// equal returns true if the two slices contain the same strings.
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// golden compares lines to the lines in testdata/<name>.golden or, if
+// UpdateEnv is set, writes them there.
+func golden(t testing.TB, name string, lines []string) {
+	t.Helper()
+	pathname := filepath.Join("testdata", name+".golden")
+	if "" != os.Getenv(UpdateEnv) {
+		if err := os.MkdirAll("testdata", 0755); nil != err {
+			t.Fatal(err)
+		}
+		s := strings.Join(lines, "\n") + "\n"
+		if err := os.WriteFile(pathname, []byte(s), 0644); nil != err {
+			t.Fatal(err)
+		}
+		return
+	}
+	p, err := os.ReadFile(pathname)
+	if nil != err {
+		t.Fatalf("%v (set %s=1 to create it)", err, UpdateEnv)
+	}
+	expected := strings.Split(strings.TrimSuffix(string(p), "\n"), "\n")
+	if !equal(expected, lines) {
+		t.Fatalf(
+			"%s differs (set %s=1 to update it):\n%s",
+			pathname,
+			UpdateEnv,
+			diff(expected, lines),
+		)
+	}
+}
+
+// glob returns true if s matches pattern, in which * matches any sequence of
+// characters and ? matches any one character.
+func glob(pattern, s string) bool {
+	for "" != pattern {
+		switch pattern[0] {
+		case '*':
+			for i := len(s); i >= 0; i-- {
+				if glob(pattern[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if "" == s {
+				return false
+			}
+		default:
+			if "" == s || pattern[0] != s[0] {
+				return false
+			}
+		}
+		pattern, s = pattern[1:], s[1:]
+	}
+	return "" == s
+}
+
+// match returns true if args match pattern.
+func match(pattern, args []string) bool {
+	for i, p := range pattern {
+		if "..." == p && len(pattern)-1 == i {
+			return true
+		}
+		if i == len(args) || !glob(p, args[i]) {
+			return false
+		}
+	}
+	return len(pattern) == len(args)
+}
+
+// quoteAll quotes each of the given commands.
+func quoteAll(cmds [][]string) []string {
+	lines := make([]string, len(cmds))
+	for i, args := range cmds {
+		lines[i] = shellac.Quote(args)
+	}
+	return lines
+}
@@ -0,0 +1,139 @@
+package shellactest
+
+import (
+	"fmt"
+	"github.com/rcrowley/go-shellac"
+	"github.com/rcrowley/go-shellac/coreutils"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestAssertArgs(t *testing.T) {
+	AssertArgs(t, []string{"-name", "*.go"}, shellac.Args(coreutils.Find{
+		Name: "*.go",
+	}))
+	ft := &fakeT{TB: t}
+	ft.run(func() { AssertArgs(ft, []string{"-name", "*.go"}, []string{"-name"}) })
+	if "arguments differ:\n- -name '*.go'\n+ -name" != ft.msg {
+		t.Fatal(ft.msg)
+	}
+}
+
+func TestAssertArgsGolden(t *testing.T) {
+	AssertArgsGolden(t, "find", shellac.Args(coreutils.Find{
+		Dirnames: []string{"."},
+		Name:     "*.go",
+		Type:     coreutils.FindFile,
+	}))
+	if "" != os.Getenv(UpdateEnv) {
+		return
+	}
+	ft := &fakeT{TB: t}
+	ft.run(func() { AssertArgsGolden(ft, "find", []string{"."}) })
+	if !strings.HasPrefix(ft.msg, "testdata/find.golden differs") {
+		t.Fatal(ft.msg)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	r := Install(t)
+	r.On(shellac.FakeResponse{Stdout: "./a.go\n"}, "find", ".", "...")
+	r.On(shellac.FakeResponse{ExitCode: 255}, "ssh", "*.example.com", "...")
+	lines, err := shellac.Command(coreutils.Find{
+		Dirnames: []string{"."},
+		Name:     "*.go",
+	}).Lines()
+	if nil != err {
+		t.Fatal(err)
+	}
+	AssertArgs(t, []string{"./a.go"}, lines)
+	if err := shellac.Run(command("ssh", "db.example.com", "hostname")); nil == err {
+		t.Fatal(err)
+	}
+	if err := shellac.Run(command("true")); nil != err {
+		t.Fatal(err)
+	}
+	r.AssertCount(t, 3)
+	r.AssertCalled(t, "ssh", "...")
+	r.AssertCalls(
+		t,
+		[]string{"find", ".", "-name", "*.go"},
+		[]string{"ssh", "db.example.com", "hostname"},
+		[]string{"true"},
+	)
+	r.AssertGolden(t, "recorder")
+}
+
+func TestRecorderAssertCalls(t *testing.T) {
+	r := Install(t)
+	shellac.Run(command("true"))
+	shellac.Run(command("echo", "hi there"))
+	ft := &fakeT{TB: t}
+	ft.run(func() { r.AssertCalls(ft, []string{"true"}, []string{"false"}) })
+	if "commands differ:\n  true\n- false\n+ echo 'hi there'" != ft.msg {
+		t.Fatal(ft.msg)
+	}
+}
+
+func TestRecorderStrict(t *testing.T) {
+	r := Install(t)
+	r.Strict = true
+	if err := shellac.Run(command("true")); nil == err {
+		t.Fatal(err)
+	}
+	r.AssertCount(t, 1)
+}
+
+func TestMatch(t *testing.T) {
+	for _, c := range []struct {
+		pattern, args []string
+		match         bool
+	}{
+		{[]string{"find"}, []string{"find"}, true},
+		{[]string{"find"}, []string{"find", "."}, false},
+		{[]string{"find", "..."}, []string{"find"}, true},
+		{[]string{"find", "..."}, []string{"find", ".", "-ls"}, true},
+		{[]string{"*", "/dev"}, []string{"find", "/dev"}, true},
+		{[]string{"f?nd", "*.go"}, []string{"find", "a/b.go"}, true},
+		{[]string{"f?nd", "*.go"}, []string{"find", "a/b.c"}, false},
+		{[]string{"...", "x"}, []string{"...", "x"}, true},
+	} {
+		if c.match != match(c.pattern, c.args) {
+			t.Fatal(c)
+		}
+	}
+}
+
+// fakeT is a testing.TB that remembers the message passed to Fatal or Fatalf
+// instead of failing the test.
+type fakeT struct {
+	testing.TB
+	msg string
+}
+
+func (t *fakeT) Fatal(args ...interface{}) {
+	t.msg = fmt.Sprint(args...)
+	panic(t)
+}
+
+func (t *fakeT) Fatalf(format string, args ...interface{}) {
+	t.msg = fmt.Sprintf(format, args...)
+	panic(t)
+}
+
+func (t *fakeT) run(f func()) {
+	defer func() {
+		if r := recover(); nil != r && t != r {
+			panic(r)
+		}
+	}()
+	f()
+}
+
+func command(args ...string) *shellac.Cmd {
+	cmd := &shellac.Cmd{}
+	cmd.Args = args
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	return cmd
+}
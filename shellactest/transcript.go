@@ -0,0 +1,333 @@
+package shellactest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/rcrowley/go-shellac"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Transcribe records or replays the commands run during the remainder of the
+// test.  If UpdateEnv is set, commands are run by shellac.LocalExecutor and
+// recorded, along with the given environment variables, to
+// testdata/<name>.transcript.json when the test ends.  Otherwise, commands are
+// replayed from that transcript and the test fails if any of it goes unused.
+func Transcribe(t testing.TB, name string, env ...string) {
+	t.Helper()
+	pathname := filepath.Join("testdata", name+".transcript.json")
+	e := shellac.DefaultExecutor
+	t.Cleanup(func() { shellac.DefaultExecutor = e })
+	if "" != os.Getenv(UpdateEnv) {
+		r := NewRecordingExecutor(shellac.LocalExecutor{}, env...)
+		shellac.DefaultExecutor = r
+		t.Cleanup(func() {
+			if err := os.MkdirAll("testdata", 0755); nil != err {
+				t.Error(err)
+			}
+			if err := r.Transcript().WriteFile(pathname); nil != err {
+				t.Error(err)
+			}
+		})
+		return
+	}
+	transcript, err := ReadTranscript(pathname)
+	if nil != err {
+		t.Fatalf("%v (set %s=1 to record it)", err, UpdateEnv)
+	}
+	r := NewReplayExecutor(transcript)
+	shellac.DefaultExecutor = r
+	t.Cleanup(func() {
+		if err := r.Done(); nil != err {
+			t.Error(err)
+		}
+	})
+}
+
+// Transcript is a record of commands that were run and how they behaved.  Its
+// JSON encoding keeps standard input, output, and error as lists of lines so
+// that changes to it are easy to review.
+type Transcript struct {
+	Commands []*TranscriptCommand `json:"commands"`
+}
+
+// ReadTranscript reads a Transcript from the named file.
+func ReadTranscript(pathname string) (*Transcript, error) {
+	p, err := os.ReadFile(pathname)
+	if nil != err {
+		return nil, err
+	}
+	t := &Transcript{}
+	if err := json.Unmarshal(p, t); nil != err {
+		return nil, fmt.Errorf("%s: %v", pathname, err)
+	}
+	return t, nil
+}
+
+// WriteFile writes the Transcript to the named file.
+func (t *Transcript) WriteFile(pathname string) error {
+	p, err := json.MarshalIndent(t, "", "\t")
+	if nil != err {
+		return err
+	}
+	return os.WriteFile(pathname, append(p, '\n'), 0644)
+}
+
+// TranscriptCommand is a record of one command.  Error is set instead of
+// ExitCode if the command couldn't be run at all.  Secrets in arguments,
+// environment variables, and standard input are redacted.
+type TranscriptCommand struct {
+	Args     []string          `json:"args"`
+	Env      map[string]string `json:"env,omitempty"`
+	Stdin    []string          `json:"stdin,omitempty"`
+	Stdout   []string          `json:"stdout,omitempty"`
+	Stderr   []string          `json:"stderr,omitempty"`
+	ExitCode int               `json:"exit_code"`
+	Error    string            `json:"error,omitempty"`
+	Duration string            `json:"duration"`
+}
+
+// RecordingExecutor is a shellac.Executor that runs commands with another
+// Executor and records them in a Transcript.
+//
+// Standard input is only recorded if it isn't an *os.File since copying from
+// a terminal would block until the user typed EOF.
+type RecordingExecutor struct {
+	env        []string
+	executor   shellac.Executor
+	mu         sync.Mutex
+	transcript Transcript
+}
+
+// NewRecordingExecutor constructs a RecordingExecutor that runs commands with
+// e and records the values of the named environment variables.
+func NewRecordingExecutor(
+	e shellac.Executor,
+	env ...string,
+) *RecordingExecutor {
+	return &RecordingExecutor{env: env, executor: e}
+}
+
+// Start starts the command and returns a Process that records it when it
+// exits.
+func (r *RecordingExecutor) Start(cmd *shellac.Cmd) (shellac.Process, error) {
+	p := &recordingProcess{
+		cmd: cmd,
+		tc: &TranscriptCommand{
+			Args: cmd.RedactedArgs(),
+			Env:  envSubset(cmd, r.env),
+		},
+		stdin:  cmd.Stdin,
+		stdout: cmd.Stdout,
+		stderr: cmd.Stderr,
+	}
+	r.mu.Lock()
+	r.transcript.Commands = append(r.transcript.Commands, p.tc)
+	r.mu.Unlock()
+	if _, ok := cmd.Stdin.(*os.File); !ok && nil != cmd.Stdin {
+		cmd.Stdin = io.TeeReader(cmd.Stdin, &p.stdinBuf)
+	}
+	cmd.Stdout = &teeWriter{mu: &p.mu, w: cmd.Stdout, b: &p.stdoutBuf}
+	cmd.Stderr = &teeWriter{mu: &p.mu, w: cmd.Stderr, b: &p.stderrBuf}
+	p.start = time.Now()
+	var err error
+	if p.Process, err = r.executor.Start(cmd); nil != err {
+		p.record(err)
+		return nil, err
+	}
+	return p, nil
+}
+
+// Transcript returns the Transcript of every command run so far.
+func (r *RecordingExecutor) Transcript() *Transcript {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &Transcript{
+		Commands: append([]*TranscriptCommand{}, r.transcript.Commands...),
+	}
+}
+
+// Unwrap returns the Executor that runs the commands so that, if it's a
+// shellac.LocalExecutor, commands are resolved as they would be without
+// recording them, including their versions and Dialects.
+func (r *RecordingExecutor) Unwrap() shellac.Executor {
+	return r.executor
+}
+
+// ReplayExecutor is a shellac.Executor that doesn't run anything.  Instead, it
+// responds to each command as recorded in a Transcript, in order, and fails
+// any command whose arguments don't match the recording.
+type ReplayExecutor struct {
+	mu         sync.Mutex
+	n          int
+	transcript *Transcript
+}
+
+// NewReplayExecutor constructs a ReplayExecutor that replays t.
+func NewReplayExecutor(t *Transcript) *ReplayExecutor {
+	return &ReplayExecutor{transcript: t}
+}
+
+// Done returns an error if any commands in the Transcript weren't replayed.
+func (r *ReplayExecutor) Done() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if rest := r.transcript.Commands[r.n:]; 0 != len(rest) {
+		cmds := make([][]string, len(rest))
+		for i, tc := range rest {
+			cmds[i] = tc.Args
+		}
+		return fmt.Errorf(
+			"shellactest: %d commands in transcript weren't run:\n%s",
+			len(rest),
+			strings.Join(quoteAll(cmds), "\n"),
+		)
+	}
+	return nil
+}
+
+// Start returns a Process that writes the recorded standard output and error
+// of the command.
+func (r *ReplayExecutor) Start(cmd *shellac.Cmd) (shellac.Process, error) {
+	e, err := r.executor(cmd)
+	if nil != err {
+		return nil, err
+	}
+	return e.Start(cmd)
+}
+
+// executor matches the command to the next one in the Transcript and returns
+// a shellac.Executor that replays it.
+func (r *ReplayExecutor) executor(cmd *shellac.Cmd) (shellac.Executor, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.n == len(r.transcript.Commands) {
+		return nil, fmt.Errorf(
+			"shellactest: command %d isn't in transcript:\n+ %s",
+			r.n+1,
+			cmd,
+		)
+	}
+	tc := r.transcript.Commands[r.n]
+	if args := cmd.RedactedArgs(); !equal(tc.Args, args) {
+		return nil, fmt.Errorf(
+			"shellactest: command %d doesn't match transcript:\n%s",
+			r.n+1,
+			diff(
+				[]string{shellac.Quote(tc.Args)},
+				[]string{shellac.Quote(args)},
+			),
+		)
+	}
+	r.n++
+	if "" != tc.Error {
+		return errorExecutor{errors.New(tc.Error)}, nil
+	}
+	return shellac.NewFakeExecutor(shellac.FakeResponse{
+		Stdout:   strings.Join(tc.Stdout, ""),
+		Stderr:   strings.Join(tc.Stderr, ""),
+		ExitCode: tc.ExitCode,
+	}), nil
+}
+
+// errorExecutor is a shellac.Executor that fails to run anything.
+type errorExecutor struct {
+	err error
+}
+
+func (e errorExecutor) Start(cmd *shellac.Cmd) (shellac.Process, error) {
+	return nil, e.err
+}
+
+// recordingProcess is a Process started by RecordingExecutor.
+type recordingProcess struct {
+	shellac.Process
+	cmd                            *shellac.Cmd
+	mu                             sync.Mutex
+	start                          time.Time
+	stdin                          io.Reader
+	stdinBuf, stdoutBuf, stderrBuf bytes.Buffer
+	stdout, stderr                 io.Writer
+	tc                             *TranscriptCommand
+}
+
+func (p *recordingProcess) Wait() error {
+	err := p.Process.Wait()
+	p.record(err)
+	return err
+}
+
+// record fills in the TranscriptCommand and reconnects standard input, output,
+// and error as they were before Start.
+func (p *recordingProcess) record(err error) {
+	p.cmd.Stdin, p.cmd.Stdout, p.cmd.Stderr = p.stdin, p.stdout, p.stderr
+	p.tc.Stdin = splitLines(p.cmd.Redact(p.stdinBuf.String()))
+	p.tc.Stdout = splitLines(p.stdoutBuf.String())
+	p.tc.Stderr = splitLines(p.stderrBuf.String())
+	p.tc.Duration = time.Since(p.start).Round(time.Millisecond).String()
+	var e *shellac.ExitError
+	if errors.As(err, &e) {
+		p.tc.ExitCode = e.ExitCode
+	} else if nil != err {
+		p.tc.Error = err.Error()
+	}
+}
+
+// envSubset returns the redacted values of the named environment variables
+// from the command's environment.
+func envSubset(cmd *shellac.Cmd, names []string) map[string]string {
+	if 0 == len(names) {
+		return nil
+	}
+	env := cmd.Env
+	if nil == env {
+		env = os.Environ()
+	}
+	m := make(map[string]string)
+	for _, kv := range env {
+		k, v, _ := strings.Cut(kv, "=")
+		for _, name := range names {
+			if k == name {
+				m[k] = cmd.Redact(v)
+			}
+		}
+	}
+	return m
+}
+
+// splitLines splits s after each newline.
+func splitLines(s string) []string {
+	if "" == s {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if "" == lines[len(lines)-1] {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// teeWriter writes to both w, if it's non-nil, and b.  Standard output and
+// error share a mutex since they may share w, too.
+type teeWriter struct {
+	b  *bytes.Buffer
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+func (t *teeWriter) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.b.Write(p)
+	if nil == t.w {
+		return len(p), nil
+	}
+	return t.w.Write(p)
+}
@@ -0,0 +1,126 @@
+package shellactest
+
+import (
+	"errors"
+	"github.com/rcrowley/go-shellac"
+	"github.com/rcrowley/go-shellac/coreutils"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordReplay(t *testing.T) {
+	r := NewRecordingExecutor(shellac.LocalExecutor{}, "SHELLACTEST")
+	cmd := &shellac.Cmd{
+		Cmd: *exec.Command("sh", "-c", "cat; echo err >&2; exit 3"),
+	}
+	cmd.Env = []string{"SHELLACTEST=yes", "OTHER=no"}
+	cmd.Stdin = strings.NewReader("foo\nbar")
+	cmd.Executor = r
+	p, err := cmd.Output()
+	var e *shellac.ExitError
+	if !errors.As(err, &e) || 3 != e.ExitCode {
+		t.Fatal(err)
+	}
+	pathname := filepath.Join(t.TempDir(), "transcript.json")
+	if err := r.Transcript().WriteFile(pathname); nil != err {
+		t.Fatal(err)
+	}
+	transcript, err := ReadTranscript(pathname)
+	if nil != err {
+		t.Fatal(err)
+	}
+	tc := transcript.Commands[0]
+	AssertArgs(t, []string{"foo\n", "bar"}, tc.Stdin)
+	AssertArgs(t, []string{"foo\n", "bar"}, tc.Stdout)
+	AssertArgs(t, []string{"err\n"}, tc.Stderr)
+	if "yes" != tc.Env["SHELLACTEST"] || 1 != len(tc.Env) {
+		t.Fatal(tc.Env)
+	}
+
+	replay := NewReplayExecutor(transcript)
+	cmd = &shellac.Cmd{
+		Cmd: *exec.Command("sh", "-c", "cat; echo err >&2; exit 3"),
+	}
+	cmd.Executor = replay
+	replayed, err := cmd.Output()
+	if !errors.As(err, &e) || 3 != e.ExitCode || "err\n" != string(e.Stderr) {
+		t.Fatal(err)
+	}
+	if string(p) != string(replayed) {
+		t.Fatal(string(replayed))
+	}
+	if err := replay.Done(); nil != err {
+		t.Fatal(err)
+	}
+}
+
+func TestRecordResolve(t *testing.T) {
+	e := shellac.DefaultExecutor
+	defer func() { shellac.DefaultExecutor = e }()
+	shellac.DefaultExecutor = NewRecordingExecutor(shellac.LocalExecutor{})
+	pathname, err := shellac.Which(coreutils.Find{})
+	if nil != err {
+		t.Fatal(err)
+	}
+	if cmd := shellac.Command(coreutils.Find{}); pathname != cmd.Path {
+		t.Fatal(cmd.Path)
+	}
+	shellac.DefaultExecutor = NewReplayExecutor(&Transcript{})
+	if cmd := shellac.Command(coreutils.Find{}); "find" != cmd.Path {
+		t.Fatal(cmd.Path)
+	}
+}
+
+func TestReplayMismatch(t *testing.T) {
+	replay := NewReplayExecutor(&Transcript{Commands: []*TranscriptCommand{
+		{Args: []string{"find", ".", "-name", "*.go"}},
+		{Args: []string{"true"}},
+	}})
+	cmd := command("find", ".", "-name", "*.c")
+	cmd.Executor = replay
+	err := cmd.Run()
+	if nil == err || strings.Join([]string{
+		"shellactest: command 1 doesn't match transcript:",
+		"- find . -name '*.go'",
+		"+ find . -name '*.c'",
+	}, "\n") != err.Error() {
+		t.Fatal(err)
+	}
+	err = replay.Done()
+	if nil == err || !strings.HasPrefix(
+		err.Error(),
+		"shellactest: 2 commands in transcript weren't run",
+	) {
+		t.Fatal(err)
+	}
+}
+
+func TestTranscribe(t *testing.T) {
+	Transcribe(t, "printf")
+	p, err := shellac.Output(exec.Command("printf", "foo\\nbar\\n"))
+	if nil != err {
+		t.Fatal(err)
+	}
+	if "foo\nbar\n" != string(p) {
+		t.Fatal(string(p))
+	}
+}
+
+func TestRecordSecrets(t *testing.T) {
+	r := NewRecordingExecutor(shellac.LocalExecutor{}, "TOKEN")
+	cmd := &shellac.Cmd{Cmd: *exec.Command("sh", "-c", "cat", "hunter2")}
+	cmd.Executor = r
+	cmd.SecretEnv("TOKEN", "hunter2")
+	cmd.SecretStdin("hunter2\n")
+	if err := cmd.Run(); nil != err {
+		t.Fatal(err)
+	}
+	tc := r.Transcript().Commands[0]
+	AssertArgs(t, []string{"sh", "-c", "cat", "***"}, tc.Args)
+	AssertArgs(t, []string{"***"}, tc.Stdin)
+	if "***" != tc.Env["TOKEN"] {
+		t.Fatal(tc.Env)
+	}
+}
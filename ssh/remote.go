@@ -0,0 +1,109 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"github.com/rcrowley/go-shellac"
+	"os/exec"
+)
+
+// ConnectionError is returned by commands from Remote when ssh(1) itself
+// fails, which it signals by exiting 255.  A remote command that exits 255
+// can't be told apart from this.
+type ConnectionError struct {
+	Hostname string
+	Err      *shellac.ExitError
+}
+
+func (e *ConnectionError) Error() string {
+	return fmt.Sprintf("ssh: %s: %v", e.Hostname, e.Err)
+}
+
+// Unwrap returns the underlying *shellac.ExitError.
+func (e *ConnectionError) Unwrap() error {
+	return e.Err
+}
+
+// Remote returns a *shellac.Cmd that runs the command described by the given
+// interface value on a remote host via ssh(1) as configured by conn.  The
+// interface value may be a *shellac.Cmd, an *exec.Cmd, or anything
+// shellac.Command accepts, in which case it's built as by RemoteDialect with
+// no Dialect.
+//
+// ssh(1) passes the command to the remote user's shell so it's quoted here to
+// arrive as the same arguments.  Build a *shellac.Cmd with
+// shellac.CommandDialect and call its Sudo or Wrap methods to run the remote
+// command via sudo(8) or wrappers on the remote host without regard to what's
+// installed locally.  If the command's Err is set, as when it's from
+// shellac.Command and isn't installed locally, so is the returned
+// *shellac.Cmd's.  If the command has a Dir, the remote shell changes to that
+// directory first.
+//
+// Environment variables the command sets or changes, including by SecretEnv,
+// are passed via env(1) on the remote host; they're redacted when logged if
+// they're secret but still appear in ssh(1)'s arguments.  Variables removed
+// from the command's environment aren't unset on the remote host.  The
+// returned *shellac.Cmd has the command's Hooks, Logger, Executor, Budget, and
+// ErrorFunc, too, and notes the command with SetRemote so that a Policy checks
+// it.
+//
+// The remote command's exit code is that of the returned *shellac.Cmd except
+// when ssh(1) itself fails, in which case the error is a *ConnectionError.
+func Remote(conn SSH, i interface{}) *shellac.Cmd {
+	return RemoteDialect(conn, "", i)
+}
+
+// RemoteDialect returns a *shellac.Cmd as Remote does but, if the given
+// interface value isn't a *shellac.Cmd or an *exec.Cmd, with its arguments in
+// the given Dialect of the command on the remote host, as by
+// shellac.CommandDialect, since nothing is run locally to detect it.
+func RemoteDialect(conn SSH, d shellac.Dialect, i interface{}) *shellac.Cmd {
+	var inner *shellac.Cmd
+	switch cmd := i.(type) {
+	case *shellac.Cmd:
+		inner = cmd
+	case *exec.Cmd:
+		inner = &shellac.Cmd{Cmd: *cmd}
+	default:
+		inner = shellac.CommandDialect(i, d)
+	}
+	args := inner.Args
+	if env := inner.ChangedEnv(); 0 != len(env) {
+		args = append(append([]string{"env"}, env...), args...)
+	}
+	command := shellac.Quote(args)
+	if "" != inner.Dir {
+		command = fmt.Sprintf("cd %s && %s", shellac.QuoteArg(inner.Dir), command)
+	}
+	conn.Command = []string{command}
+	cmd := shellac.Command(conn)
+	if nil == cmd.Err {
+		cmd.Err = inner.Err
+	}
+	cmd.Secret(inner.Secrets()...)
+	if nil != inner.Stdin {
+		cmd.Stdin = inner.Stdin
+	}
+	if nil != inner.Stdout {
+		cmd.Stdout = inner.Stdout
+	}
+	if nil != inner.Stderr {
+		cmd.Stderr = inner.Stderr
+	}
+	cmd.SetRemote(inner)
+	cmd.Hooks = inner.Hooks
+	cmd.Logger = inner.Logger
+	cmd.Executor = inner.Executor
+	cmd.Budget = inner.Budget
+	cmd.ErrorFunc = func(err error) error {
+		var e *shellac.ExitError
+		if errors.As(err, &e) && 255 == e.ExitCode {
+			return &ConnectionError{Hostname: conn.Hostname, Err: e}
+		}
+		if nil != inner.ErrorFunc {
+			return inner.ErrorFunc(err)
+		}
+		return err
+	}
+	return cmd
+}
@@ -10,7 +10,7 @@ import (
 type SSH struct {
 
 	// -1
-	SSHv1 bool `flag:"-1"`
+	SSHv1 bool `flag:"-1" until:"7.6"`
 
 	// -2
 	SSHv2 bool `flag:"-2"`
@@ -52,7 +52,7 @@ type SSH struct {
 	AllowRemoteConnectionsToLocalForwardedPorts bool `flag:"-g"`
 
 	// -I <pkcs11>
-	PKCS11 string `flag:"-I"`
+	PKCS11 string `flag:"-I" since:"5.4"`
 
 	// -i <identity>
 	Identity string `flag:"-i"`
@@ -139,6 +139,12 @@ type SSH struct {
 	Command []string `pos:"last"`
 }
 
+// VersionProbe returns the arguments that make ssh(1) print its version and a
+// pattern that matches it.
+func (SSH) VersionProbe() ([]string, string) {
+	return []string{"-V"}, `OpenSSH_(\d+(?:\.\d+)+)`
+}
+
 // SSHOptions is a map of options as specified in ssh_config(5) files.
 type SSHOptions map[string]string
 
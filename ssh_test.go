@@ -1,19 +1,246 @@
-package shellac
+package shellac_test
 
 import (
+	"errors"
+	"github.com/rcrowley/go-shellac"
+	"github.com/rcrowley/go-shellac/coreutils"
+	"github.com/rcrowley/go-shellac/shellactest"
 	"github.com/rcrowley/go-shellac/ssh"
+	"io"
+	"os/exec"
 	"testing"
+	"time"
 )
 
 func TestSSH(t *testing.T) {
-	testArgs(t, []string{}, Args(ssh.SSH{}))
-	testArgs(t, []string{"example.com"}, Args(ssh.SSH{
+	shellactest.AssertArgs(t, []string{}, shellac.Args(ssh.SSH{}))
+	shellactest.AssertArgs(t, []string{"example.com"}, shellac.Args(ssh.SSH{
 		Hostname: "example.com",
 	}))
 }
 
 func TestSSHOptions(t *testing.T) {
-	testArgs(t, []string{"example.com"}, Args(ssh.SSH{
+	shellactest.AssertArgs(t, []string{"example.com"}, shellac.Args(ssh.SSH{
 		Hostname: "example.com",
 	}))
 }
+
+func TestSSHRemote(t *testing.T) {
+	cmd := ssh.Remote(ssh.SSH{Hostname: "example.com"}, coreutils.Find{
+		Dirnames: []string{"."},
+		Name:     "*.go",
+	})
+	shellactest.AssertArgs(t, []string{
+		"ssh", "example.com", "find . -name '*.go'",
+	}, cmd.Args)
+}
+
+func TestSSHRemoteDialect(t *testing.T) {
+	defer func(binaries map[string]string) { shellac.Binaries = binaries }(shellac.Binaries)
+	shellac.Binaries = map[string]string{"find": "/nonexistent/find"}
+	conn := ssh.SSH{Hostname: "example.com"}
+	cmd := ssh.RemoteDialect(conn, shellac.DialectBusyBox, coreutils.Find{
+		Dirnames:                 []string{"."},
+		WholenameCaseInsensitive: "*/x",
+		Readable:                 true,
+	})
+	shellactest.AssertArgs(t, []string{
+		"ssh", "example.com", "find . -iwholename '*/x' -readable",
+	}, cmd.Args)
+	var e *shellac.DialectError
+	if !errors.As(cmd.Err, &e) || shellac.DialectBusyBox != e.Dialect {
+		t.Fatal(cmd.Err)
+	}
+	if err := cmd.Run(); cmd.Err != err {
+		t.Fatal(err)
+	}
+	cmd = ssh.RemoteDialect(conn, shellac.DialectBusyBox, coreutils.Find{
+		Dirnames:                 []string{"."},
+		WholenameCaseInsensitive: "*/x",
+	})
+	shellactest.AssertArgs(t, []string{
+		"ssh", "example.com", "find . -ipath '*/x'",
+	}, cmd.Args)
+	if nil != cmd.Err {
+		t.Fatal(cmd.Err)
+	}
+}
+
+func TestSSHRemoteDialectRecorder(t *testing.T) {
+	r := shellactest.Install(t)
+	cmd := ssh.RemoteDialect(
+		ssh.SSH{Hostname: "example.com"},
+		shellac.DialectBusyBox,
+		coreutils.Find{Readable: true},
+	)
+	var e *shellac.DialectError
+	if err := cmd.Run(); !errors.As(err, &e) || "-readable" != e.Flag {
+		t.Fatal(err)
+	}
+	r.AssertCount(t, 0)
+}
+
+func TestSSHRemoteDir(t *testing.T) {
+	inner := shellac.Command(coreutils.Find{Dirnames: []string{"."}})
+	inner.Dir = "/home/it's me"
+	cmd := ssh.Remote(ssh.SSH{Hostname: "example.com"}, inner)
+	shellactest.AssertArgs(t, []string{
+		"ssh", "example.com", `cd '/home/it'\''s me' && find .`,
+	}, cmd.Args)
+}
+
+func TestSSHRemoteExitCode(t *testing.T) {
+	r := shellactest.Install(t)
+	r.On(shellac.FakeResponse{ExitCode: 1}, "ssh", "a.example.com", "...")
+	r.On(shellac.FakeResponse{ExitCode: 255}, "ssh", "b.example.com", "...")
+	err := ssh.Remote(ssh.SSH{Hostname: "a.example.com"}, coreutils.Find{}).Run()
+	var e *shellac.ExitError
+	var connErr *ssh.ConnectionError
+	if !errors.As(err, &e) || 1 != e.ExitCode || errors.As(err, &connErr) {
+		t.Fatal(err)
+	}
+	err = ssh.Remote(ssh.SSH{Hostname: "b.example.com"}, coreutils.Find{}).Run()
+	if !errors.As(err, &connErr) || "b.example.com" != connErr.Hostname {
+		t.Fatal(err)
+	}
+	if !errors.As(err, &e) || 255 != e.ExitCode {
+		t.Fatal(err)
+	}
+}
+
+func TestSSHRemoteEnv(t *testing.T) {
+	t.Setenv("SHELLAC_UNCHANGED", "yes")
+	inner := shellac.Command(coreutils.Find{Dirnames: []string{"."}})
+	inner.SecretEnv("TOKEN", "hunter2")
+	inner.Logger = shellac.NewTextLogger(io.Discard)
+	inner.Budget = &shellac.Budget{Duration: time.Second}
+	inner.Hooks = []shellac.Hook{shellac.NopHook{}}
+	inner.Executor = shellac.DryRunExecutor{}
+	cmd := ssh.Remote(ssh.SSH{Hostname: "example.com"}, inner)
+	shellactest.AssertArgs(t, []string{
+		"ssh", "example.com", "env TOKEN=hunter2 find .",
+	}, cmd.Args)
+	if `ssh example.com 'env TOKEN=*** find .'` != cmd.String() {
+		t.Fatal(cmd)
+	}
+	if inner.Logger != cmd.Logger || inner.Budget != cmd.Budget {
+		t.Fatal(cmd)
+	}
+	if 1 != len(cmd.Hooks) || inner.Executor != cmd.Executor {
+		t.Fatal(cmd)
+	}
+	if err := cmd.Run(); nil != err {
+		t.Fatal(err)
+	}
+}
+
+func TestSSHRemoteSecret(t *testing.T) {
+	inner := shellac.Command(coreutils.Find{Name: "hunter 2"})
+	inner.Secret("hunter 2")
+	cmd := ssh.Remote(ssh.SSH{Hostname: "example.com"}, inner)
+	if `ssh example.com 'find -name '\''***'\'''` != cmd.String() {
+		t.Fatal(cmd)
+	}
+}
+
+func TestSSHRemoteSudo(t *testing.T) {
+	defer func(binaries map[string]string) { shellac.Binaries = binaries }(shellac.Binaries)
+	shellac.Binaries = map[string]string{
+		"find": "/nonexistent/find",
+		"nice": "/nonexistent/nice",
+		"sudo": "/nonexistent/sudo",
+	}
+	inner := shellac.CommandDialect(coreutils.Find{Dirnames: []string{"/root"}}, "")
+	inner.Wrap(coreutils.Nice{Adjustment: shellac.NewInt(10)})
+	inner.Sudo(shellac.SudoOptions{User: "postgres"})
+	cmd := ssh.Remote(ssh.SSH{Hostname: "example.com"}, inner)
+	shellactest.AssertArgs(t, []string{
+		"ssh", "example.com", "sudo -u postgres nice -n 10 find /root",
+	}, cmd.Args)
+	if nil != cmd.Err {
+		t.Fatal(cmd.Err)
+	}
+	inner = shellac.Command(coreutils.Find{Dirnames: []string{"/root"}})
+	cmd = ssh.Remote(ssh.SSH{Hostname: "example.com"}, inner)
+	if nil == inner.Err || inner.Err != cmd.Err {
+		t.Fatal(cmd.Err)
+	}
+}
+
+func TestSSHPolicy(t *testing.T) {
+	p := &shellac.Policy{Forbid: []shellac.PolicyRule{
+		{Name: "no-proxy", Type: "ssh.SSH", Field: "Options", Key: "ProxyCommand"},
+		{Name: "no-delete", Type: "Find", Field: "Delete"},
+	}}
+	cmd := ssh.Remote(ssh.SSH{
+		Hostname: "example.com",
+		Options:  ssh.SSHOptions{"User": "rcrowley"},
+	}, coreutils.Find{Dirnames: []string{"."}})
+	if err := p.Check(cmd); nil != err {
+		t.Fatal(err)
+	}
+	cmd = ssh.Remote(ssh.SSH{
+		Hostname: "example.com",
+		Options:  ssh.SSHOptions{"proxycommand": "nc %h %p"},
+	}, coreutils.Find{Dirnames: []string{"."}})
+	testSSHPolicyError(t, "no-proxy", p.Check(cmd))
+	cmd = ssh.Remote(ssh.SSH{Hostname: "example.com"}, coreutils.Find{
+		Dirnames: []string{"."},
+		Delete:   true,
+	})
+	testSSHPolicyError(t, "no-delete", p.Check(cmd))
+}
+
+func TestSSHPolicyCommands(t *testing.T) {
+	p := &shellac.Policy{Commands: []string{"ssh", "find"}}
+	cmd := ssh.Remote(ssh.SSH{Hostname: "example.com"}, coreutils.Find{})
+	if err := p.Check(cmd); nil != err {
+		t.Fatal(err)
+	}
+	cmd = ssh.Remote(
+		ssh.SSH{Hostname: "example.com"},
+		exec.Command("rm", "-rf", "/"),
+	)
+	testSSHPolicyError(t, "commands", p.Check(cmd))
+	inner := shellac.Command(coreutils.Find{})
+	inner.Wrap(coreutils.Nice{})
+	cmd = ssh.Remote(ssh.SSH{Hostname: "example.com"}, inner)
+	testSSHPolicyError(t, "commands", p.Check(cmd))
+}
+
+func TestSSHPolicySudoUsers(t *testing.T) {
+	p := &shellac.Policy{SudoUsers: []string{"postgres"}}
+	inner := shellac.Command(coreutils.Find{})
+	inner.Sudo(shellac.SudoOptions{User: "postgres"})
+	cmd := ssh.Remote(ssh.SSH{Hostname: "example.com"}, inner)
+	if err := p.Check(cmd); nil != err {
+		t.Fatal(err)
+	}
+	inner = shellac.Command(coreutils.Find{})
+	inner.Sudo(shellac.SudoOptions{User: "root"})
+	cmd = ssh.Remote(ssh.SSH{Hostname: "example.com"}, inner)
+	testSSHPolicyError(t, "sudo_users", p.Check(cmd))
+}
+
+func TestSSHVersion(t *testing.T) {
+	version, err := shellac.InstalledVersion(ssh.SSH{})
+	if nil != err {
+		t.Skip(err)
+	}
+	if 0 > version.Compare(shellac.Version{7, 6}) {
+		t.Skip(version)
+	}
+	cmd := shellac.Command(ssh.SSH{SSHv1: true, Hostname: "example.com"})
+	var e *shellac.VersionError
+	if !errors.As(cmd.Err, &e) || "-1" != e.Flag || "7.6" != e.Until {
+		t.Fatal(cmd.Err)
+	}
+}
+
+func testSSHPolicyError(t *testing.T, rule string, err error) {
+	t.Helper()
+	var e *shellac.PolicyError
+	if !errors.As(err, &e) || rule != e.Rule {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,82 @@
+package shellac
+
+// Escalator modifies a command to be run with different privileges.
+type Escalator interface {
+	Escalate(cmd *Cmd) error
+}
+
+// DoasOptions is an Escalator that runs commands via doas(1).
+type DoasOptions struct {
+	_ struct{} `command:"doas"`
+
+	// -n
+	NonInteractive bool `flag:"-n"`
+
+	// -u <user>
+	User string `flag:"-u"`
+}
+
+// Escalate modifies the command to be run via doas(1).
+func (o DoasOptions) Escalate(cmd *Cmd) error {
+	return cmd.escalate(o)
+}
+
+// RunuserOptions is an Escalator that runs commands via runuser(1), which is
+// only available to root.
+type RunuserOptions struct {
+	_ struct{} `command:"runuser"`
+
+	// -g <group>
+	Group string `flag:"-g"`
+
+	// -G <group>
+	SupplementaryGroup string `flag:"-G"`
+
+	// -m
+	PreserveEnv bool `flag:"-m"`
+
+	// -u <user>
+	User string `flag:"-u"`
+}
+
+// Escalate modifies the command to be run via runuser(1).  User is required.
+func (o RunuserOptions) Escalate(cmd *Cmd) error {
+	return cmd.escalate(o)
+}
+
+// SudoOptions is an Escalator that runs commands via sudo(8).  The zero value
+// runs commands as root, which is the default.
+type SudoOptions struct {
+	_ struct{} `command:"sudo"`
+
+	// -E
+	PreserveEnv bool `flag:"-E"`
+
+	// --preserve-env=<list>, where list is comma-separated
+	PreserveEnvList string `flag:"--preserve-env" sep:"="`
+
+	// -g <group>
+	Group string `flag:"-g"`
+
+	// -H
+	SetHome bool `flag:"-H"`
+
+	// -n
+	NonInteractive bool `flag:"-n"`
+
+	// -u <user>
+	User string `flag:"-u"`
+}
+
+// Escalate modifies the command to be run via sudo(8).
+func (o SudoOptions) Escalate(cmd *Cmd) error {
+	return cmd.escalate(o)
+}
+
+// escalate modifies the command to be run via the command described by the
+// given interface value.
+func (cmd *Cmd) escalate(i interface{}) error {
+	prefix, err := cmd.prefix(i)
+	cmd.sudo = append(prefix, cmd.sudo...)
+	return err
+}
@@ -0,0 +1,142 @@
+package shellac
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// OnStderrLine calls fn with each line of standard error, without its
+// trailing newline, alongside any channels, callbacks, or tees already
+// connected.  A final line that isn't terminated by a newline is passed to fn
+// when the command exits.
+func (cmd *Cmd) OnStderrLine(fn func(line string)) {
+	cmd.Stderr = addWriter(cmd.Stderr, os.Stderr, newLineWriter(fn))
+}
+
+// OnStdoutLine calls fn with each line of standard output, without its
+// trailing newline, alongside any channels, callbacks, or tees already
+// connected.  A final line that isn't terminated by a newline is passed to fn
+// when the command exits.
+func (cmd *Cmd) OnStdoutLine(fn func(line string)) {
+	cmd.Stdout = addWriter(cmd.Stdout, os.Stdout, newLineWriter(fn))
+}
+
+// TeeStderr copies standard error to each of the given writers alongside any
+// channels, callbacks, or tees already connected.  The first of these replaces
+// os.Stderr so include it to keep writing there, too.
+func (cmd *Cmd) TeeStderr(w ...io.Writer) {
+	for _, w := range w {
+		cmd.Stderr = addWriter(cmd.Stderr, os.Stderr, w)
+	}
+}
+
+// TeeStdout copies standard output to each of the given writers alongside any
+// channels, callbacks, or tees already connected.  The first of these replaces
+// os.Stdout so include it to keep writing there, too.
+func (cmd *Cmd) TeeStdout(w ...io.Writer) {
+	for _, w := range w {
+		cmd.Stdout = addWriter(cmd.Stdout, os.Stdout, w)
+	}
+}
+
+// addWriter returns a writer that writes to w and prev, unless prev is nil or
+// the default writer def, in which case w replaces it.
+func addWriter(prev io.Writer, def *os.File, w io.Writer) io.Writer {
+	if m, ok := prev.(*multiWriter); ok {
+		m.writers = append(m.writers, w)
+		return m
+	}
+	if isDefault(prev, def) {
+		return &multiWriter{writers: []io.Writer{w}}
+	}
+	return &multiWriter{writers: []io.Writer{prev, w}}
+}
+
+// closeWriter calls Close on w if it's a ChanWriter, ByteChanWriter, or one
+// of the writers behind OnStdoutLine, TeeStdout, and the like.  Other writers
+// belong to the caller and are left open.
+func closeWriter(w io.Writer) error {
+	switch w := w.(type) {
+	case *ByteChanWriter:
+		return w.Close()
+	case *ChanWriter:
+		return w.Close()
+	case *lineWriter:
+		return w.Close()
+	case *multiWriter:
+		return w.Close()
+	}
+	return nil
+}
+
+// lineWriter is an io.Writer that calls a function with each line written to
+// it, without its trailing newline.
+type lineWriter struct {
+	buffer bytes.Buffer
+	close  func() error // called by Close after the final line, if non-nil
+	fn     func(string)
+}
+
+// newLineWriter constructs a lineWriter that calls fn with each line.
+func newLineWriter(fn func(string)) *lineWriter {
+	return &lineWriter{fn: fn}
+}
+
+// Close calls the function with the final line if it wasn't terminated by a
+// newline, as ChanWriter.Close does.
+func (w *lineWriter) Close() error {
+	if s := w.buffer.String(); "" != s {
+		w.buffer.Reset()
+		w.fn(s)
+	}
+	if nil != w.close {
+		return w.close()
+	}
+	return nil
+}
+
+// Write calls the function with each complete line.
+func (w *lineWriter) Write(p []byte) (int, error) {
+	n, err := w.buffer.Write(p)
+	for i := bytes.Count(p, []byte{'\n'}); i > 0; i-- {
+		s, err := w.buffer.ReadString('\n')
+		if nil != err {
+			break
+		}
+		w.fn(s[:len(s)-1])
+	}
+	return n, err
+}
+
+// multiWriter is an io.Writer that writes to each of its writers in turn, as
+// io.MultiWriter does, and that can be closed.
+type multiWriter struct {
+	writers []io.Writer
+}
+
+// Close closes each writer that closeWriter would and returns the first
+// error.
+func (m *multiWriter) Close() error {
+	var err error
+	for _, w := range m.writers {
+		if e := closeWriter(w); nil != e && nil == err {
+			err = e
+		}
+	}
+	return err
+}
+
+// Write writes p to each writer and stops at the first error.
+func (m *multiWriter) Write(p []byte) (int, error) {
+	for _, w := range m.writers {
+		n, err := w.Write(p)
+		if nil != err {
+			return n, err
+		}
+		if len(p) != n {
+			return n, io.ErrShortWrite
+		}
+	}
+	return len(p), nil
+}
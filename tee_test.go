@@ -0,0 +1,75 @@
+package shellac
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"testing"
+)
+
+func TestOnStderrLine(t *testing.T) {
+	cmd := toCmd(exec.Command("sh", "-c", "echo a >&2; printf b >&2"))
+	var lines []string
+	cmd.OnStderrLine(func(line string) { lines = append(lines, line) })
+	if err := cmd.Run(); nil != err {
+		t.Fatal(err)
+	}
+	if 2 != len(lines) || "a" != lines[0] || "b" != lines[1] {
+		t.Fatal(lines)
+	}
+}
+
+func TestOnStdoutLineChannelStdout(t *testing.T) {
+	cmd := toCmd(exec.Command("sh", "-c", "echo a; printf b"))
+	ch := make(chan string)
+	cmd.ChannelStdout(ch)
+	var lines []string
+	cmd.OnStdoutLine(func(line string) { lines = append(lines, line) })
+	done := make(chan error)
+	go func() { done <- cmd.Run() }()
+	var received []string
+	for s := range ch {
+		received = append(received, s)
+	}
+	if 2 != len(received) || "a" != received[0] || "b" != received[1] {
+		t.Fatal(received)
+	}
+	if err := <-done; nil != err {
+		t.Fatal(err)
+	}
+	if 2 != len(lines) || "a" != lines[0] || "b" != lines[1] {
+		t.Fatal(lines)
+	}
+}
+
+func TestTeeStdout(t *testing.T) {
+	cmd := toCmd(exec.Command("echo", "hi"))
+	var b1, b2 bytes.Buffer
+	cmd.TeeStdout(&b1)
+	ch := make(chan string, 1)
+	cmd.ChannelStdout(ch)
+	cmd.TeeStdout(&b2)
+	if err := cmd.Run(); nil != err {
+		t.Fatal(err)
+	}
+	if "hi\n" != b1.String() || "hi\n" != b2.String() {
+		t.Fatal(b1.String(), b2.String())
+	}
+	if s := <-ch; "hi" != s {
+		t.Fatal(s)
+	}
+	if _, ok := <-ch; ok {
+		t.Fatal(ok)
+	}
+}
+
+func TestTeeStdoutReplacesDefault(t *testing.T) {
+	cmd := toCmd(exec.Command("echo", "hi"))
+	cmd.Stdout = os.Stdout
+	var b bytes.Buffer
+	cmd.TeeStdout(&b)
+	m, ok := cmd.Stdout.(*multiWriter)
+	if !ok || 1 != len(m.writers) {
+		t.Fatal(cmd.Stdout)
+	}
+}
@@ -0,0 +1,2 @@
+// Shellac structs for util-linux.
+package utillinux
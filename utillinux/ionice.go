@@ -0,0 +1,25 @@
+package utillinux
+
+// ionice(1)
+type Ionice struct {
+
+	// -c <class>
+	Class IoniceClass `flag:"-c"`
+
+	// -n <level>
+	Level *int `flag:"-n"`
+
+	// -t
+	IgnoreFailure bool `flag:"-t"`
+}
+
+// IoniceClass is an enumeration of all possible values of ionice(1)'s -c
+// option, exposed as Class in Ionice.
+type IoniceClass int
+
+var (
+	IoniceNone       IoniceClass = 0
+	IoniceRealtime   IoniceClass = 1
+	IoniceBestEffort IoniceClass = 2
+	IoniceIdle       IoniceClass = 3
+)
@@ -0,0 +1,47 @@
+package utillinux
+
+// nsenter(1)
+type Nsenter struct {
+
+	// -t <pid>
+	Target int `flag:"-t"`
+
+	// -a
+	All bool `flag:"-a"`
+
+	// -C
+	Cgroup bool `flag:"-C"`
+
+	// -i
+	IPC bool `flag:"-i"`
+
+	// -m
+	Mount bool `flag:"-m"`
+
+	// -n
+	Net bool `flag:"-n"`
+
+	// -p
+	PID bool `flag:"-p"`
+
+	// -U
+	User bool `flag:"-U"`
+
+	// -u
+	UTS bool `flag:"-u"`
+
+	// -F
+	NoFork bool `flag:"-F"`
+
+	// -G <gid>
+	GID *int `flag:"-G"`
+
+	// -S <uid>
+	UID *int `flag:"-S"`
+
+	// -r
+	Root bool `flag:"-r"`
+
+	// -w
+	WorkingDirectory bool `flag:"-w"`
+}
@@ -0,0 +1,264 @@
+package shellac
+
+import (
+	"fmt"
+	"os/exec"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	versionMu     sync.Mutex
+	versionProbes = map[string]VersionProbe{}
+	probed        = map[string]probeOutput{}
+)
+
+// CheckVersion returns a *VersionError if any field set in the given interface
+// value has a since or until tag that rules out the installed version of the
+// command it describes.  Commands whose installed version can't be found, as
+// when they have no VersionProbe, aren't checked.
+func CheckVersion(i interface{}) error {
+	v := reflect.Indirect(reflect.ValueOf(i))
+	t := v.Type()
+	var installed Version
+	for j := 0; j < t.NumField(); j++ {
+		f := t.Field(j)
+		since, until := f.Tag.Get("since"), f.Tag.Get("until")
+		if ("" == since && "" == until) || 0 == len(field(f, v.Field(j))) {
+			continue
+		}
+		if nil == installed {
+			var err error
+			if installed, err = InstalledVersion(i); nil != err {
+				return nil
+			}
+		}
+		ok, err := installed.between(since, until)
+		if nil != err {
+			return fmt.Errorf("shellac: %s.%s: %v", typeName(t), f.Name, err)
+		}
+		if !ok {
+			return &VersionError{
+				Command: command(t),
+				Flag:    flagName(f),
+				Version: installed,
+				Since:   since,
+				Until:   until,
+			}
+		}
+	}
+	return nil
+}
+
+// InstalledVersion returns the version of the executable that runs the command
+// described by the given interface value, found as by Which, by running the
+// VersionProbe registered for it or else the one it describes as a
+// VersionProber.  Its output is remembered for each executable, as by
+// DetectDialect, so it's only run once.
+func InstalledVersion(i interface{}) (Version, error) {
+	t := reflect.TypeOf(i)
+	if reflect.Ptr == t.Kind() {
+		t = t.Elem()
+	}
+	name := command(t)
+	vp, err := versionProbe(i, t)
+	if nil != err {
+		return nil, err
+	}
+	pathname, err := which(t)
+	if nil != err {
+		return nil, err
+	}
+	p, err := probe(pathname, vp.Args)
+	if nil != err {
+		return nil, fmt.Errorf("shellac: %s %s: %v", name, Quote(vp.Args), err)
+	}
+	m := vp.Regexp.FindSubmatch(p)
+	if 2 > len(m) {
+		return nil, fmt.Errorf("shellac: can't find %s's version in %q", name, p)
+	}
+	return ParseVersion(string(m[1]))
+}
+
+// RegisterVersionProbe sets how to find the installed version of the named
+// command, as for structs that aren't VersionProbers or whose executables,
+// per Binaries, print their versions differently.
+func RegisterVersionProbe(name string, probe VersionProbe) {
+	versionMu.Lock()
+	defer versionMu.Unlock()
+	versionProbes[name] = probe
+}
+
+// Version is a dotted version number, as in 4.9.0.
+type Version []int
+
+// ParseVersion parses a dotted version number.  Anything after the last
+// number, as in 9.6p1, is ignored.
+func ParseVersion(s string) (Version, error) {
+	var v Version
+	for _, part := range strings.Split(s, ".") {
+		end := strings.IndexFunc(part, func(r rune) bool {
+			return r < '0' || '9' < r
+		})
+		if -1 != end {
+			part = part[:end]
+		}
+		n, err := strconv.Atoi(part)
+		if nil != err {
+			return nil, fmt.Errorf("shellac: malformed version %q", s)
+		}
+		v = append(v, n)
+		if -1 != end {
+			break
+		}
+	}
+	return v, nil
+}
+
+// Compare returns -1, 0, or 1 as v is older than, the same as, or newer than
+// w.  Missing numbers are taken to be 0 so 4.9 and 4.9.0 are the same.
+func (v Version) Compare(w Version) int {
+	for i := 0; i < len(v) || i < len(w); i++ {
+		var a, b int
+		if i < len(v) {
+			a = v[i]
+		}
+		if i < len(w) {
+			b = w[i]
+		}
+		if a < b {
+			return -1
+		}
+		if a > b {
+			return 1
+		}
+	}
+	return 0
+}
+
+// String returns the dotted version number.
+func (v Version) String() string {
+	parts := make([]string, len(v))
+	for i, n := range v {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ".")
+}
+
+// between returns true if v is at least since, if given, and older than
+// until, if given.
+func (v Version) between(since, until string) (bool, error) {
+	if "" != since {
+		w, err := ParseVersion(since)
+		if nil != err {
+			return false, err
+		}
+		if 0 > v.Compare(w) {
+			return false, nil
+		}
+	}
+	if "" != until {
+		w, err := ParseVersion(until)
+		if nil != err {
+			return false, err
+		}
+		if 0 <= v.Compare(w) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// VersionError describes a flag the installed version of a command doesn't
+// support.
+type VersionError struct {
+	Command string
+	Flag    string
+	Version Version // installed
+	Since   string  // first version that supports the flag, if any
+	Until   string  // first version that doesn't, if any
+}
+
+// Error names the command, its installed version, and the versions that
+// support the flag.
+func (e *VersionError) Error() string {
+	var supported []string
+	if "" != e.Since {
+		supported = append(supported, "since "+e.Since)
+	}
+	if "" != e.Until {
+		supported = append(supported, "until "+e.Until)
+	}
+	return fmt.Sprintf(
+		"shellac: %s %v doesn't support %s (supported %s)",
+		e.Command,
+		e.Version,
+		e.Flag,
+		strings.Join(supported, " and "),
+	)
+}
+
+// VersionProbe describes how to find the installed version of a command.
+type VersionProbe struct {
+	Args   []string       // that make it print its version
+	Regexp *regexp.Regexp // whose first submatch in its output is the version
+}
+
+// VersionProber is implemented by structs that describe how to find the
+// installed version of their commands, as coreutils.Find does.  It uses only
+// standard types so that packages of structs needn't import this one.
+type VersionProber interface {
+	// VersionProbe returns the arguments that make the command print its
+	// version and a regular expression whose first submatch in its output is
+	// the version.
+	VersionProbe() (args []string, pattern string)
+}
+
+// probe returns the combined output of the named executable run with the
+// given arguments, remembered for each executable and arguments.
+func probe(pathname string, args []string) ([]byte, error) {
+	key := Quote(append([]string{pathname}, args...))
+	versionMu.Lock()
+	out, ok := probed[key]
+	versionMu.Unlock()
+	if ok {
+		return out.p, out.err
+	}
+	out.p, out.err = exec.Command(pathname, args...).CombinedOutput()
+	versionMu.Lock()
+	probed[key] = out
+	versionMu.Unlock()
+	return out.p, out.err
+}
+
+// probeOutput is the remembered result of probe.
+type probeOutput struct {
+	p   []byte
+	err error
+}
+
+// versionProbe returns the VersionProbe registered for the command described
+// by the given interface value and struct type or else the one it describes as
+// a VersionProber.
+func versionProbe(i interface{}, t reflect.Type) (VersionProbe, error) {
+	name := command(t)
+	versionMu.Lock()
+	vp, ok := versionProbes[name]
+	versionMu.Unlock()
+	if ok {
+		return vp, nil
+	}
+	prober, ok := i.(VersionProber)
+	if !ok {
+		return VersionProbe{}, fmt.Errorf("shellac: no version probe for %s", name)
+	}
+	args, pattern := prober.VersionProbe()
+	re, err := regexp.Compile(pattern)
+	if nil != err {
+		return VersionProbe{}, err
+	}
+	return VersionProbe{Args: args, Regexp: re}, nil
+}
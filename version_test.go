@@ -0,0 +1,163 @@
+package shellac
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestCheckVersion(t *testing.T) {
+	defer func(path []string) { SearchPath = path }(SearchPath)
+	SearchPath = []string{testScripts(t, testVersionScript, "testversion")}
+	if err := CheckVersion(testVersion{Current: true}); nil != err {
+		t.Fatal(err)
+	}
+	for _, i := range []interface{}{
+		testVersion{Old: true},
+		&testVersion{New: "yes"},
+	} {
+		var e *VersionError
+		if err := CheckVersion(i); !errors.As(err, &e) {
+			t.Fatal(err)
+		}
+	}
+	cmd := Command(testVersion{New: "yes"})
+	if nil == cmd.Err || "shellac: testversion 2.5 doesn't support -n (supported since 3.0)" != cmd.Err.Error() {
+		t.Fatal(cmd.Err)
+	}
+	if err := cmd.Run(); cmd.Err != err {
+		t.Fatal(err)
+	}
+	cmd = Command(testVersion{Old: true})
+	if nil == cmd.Err || "shellac: testversion 2.5 doesn't support -o (supported until 2.0)" != cmd.Err.Error() {
+		t.Fatal(cmd.Err)
+	}
+}
+
+func TestCheckVersionExecutor(t *testing.T) {
+	defer func(path []string) { SearchPath = path }(SearchPath)
+	dirname := testScripts(t, testVersionScript, "testversion")
+	SearchPath = []string{dirname}
+	defer func(e Executor) { DefaultExecutor = e }(DefaultExecutor)
+	DefaultExecutor = DryRunExecutor{}
+	cmd := Command(testVersion{New: "yes"})
+	if nil != cmd.Err {
+		t.Fatal(cmd.Err)
+	}
+	DefaultExecutor = LocalExecutor{}
+	cmd = Command(testDefault{})
+	cmd.Executor = NewFakeExecutor()
+	if err := cmd.Wrap(testVersion{New: "yes"}); nil != err {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dirname, "probes")); !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckVersionUnknown(t *testing.T) {
+	if err := CheckVersion(testDefault{}); nil != err {
+		t.Fatal(err)
+	}
+	defer func(path []string) { SearchPath = path }(SearchPath)
+	SearchPath = []string{t.TempDir()}
+	if err := CheckVersion(testVersion{New: "yes"}); nil != err {
+		t.Fatal(err)
+	}
+}
+
+func TestInstalledVersion(t *testing.T) {
+	defer func(path []string) { SearchPath = path }(SearchPath)
+	dirname := testScripts(t, testVersionScript, "testversion")
+	SearchPath = []string{dirname}
+	for i := 0; i < 2; i++ {
+		version, err := InstalledVersion(testVersion{})
+		if nil != err {
+			t.Fatal(err)
+		}
+		if "2.5" != version.String() {
+			t.Fatal(version)
+		}
+	}
+	p, err := os.ReadFile(filepath.Join(dirname, "probes"))
+	if nil != err {
+		t.Fatal(err)
+	}
+	if "--version\n" != string(p) {
+		t.Fatal(string(p))
+	}
+	if _, err := InstalledVersion(testDefault{}); nil == err {
+		t.Fatal(err)
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	for s, want := range map[string]string{
+		"4.9.0": "4.9.0",
+		"9.2p1": "9.2",
+		"10":    "10",
+	} {
+		version, err := ParseVersion(s)
+		if nil != err {
+			t.Fatal(err)
+		}
+		if want != version.String() {
+			t.Fatal(version)
+		}
+	}
+	for _, s := range []string{"", "v1", "1..2"} {
+		if _, err := ParseVersion(s); nil == err {
+			t.Fatal(s)
+		}
+	}
+}
+
+func TestRegisterVersionProbe(t *testing.T) {
+	defer func(path []string) { SearchPath = path }(SearchPath)
+	SearchPath = []string{testScripts(t, testVersionScript, "testregistered")}
+	RegisterVersionProbe("testregistered", VersionProbe{
+		Args:   []string{"-V"},
+		Regexp: regexp.MustCompile(`version (\d+(?:\.\d+)+)`),
+	})
+	version, err := InstalledVersion(testRegistered{})
+	if nil != err {
+		t.Fatal(err)
+	}
+	if "2.5" != version.String() {
+		t.Fatal(version)
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	for _, c := range []struct {
+		v, w Version
+		want int
+	}{
+		{Version{4, 9}, Version{4, 9, 0}, 0},
+		{Version{4, 10}, Version{4, 9, 1}, 1},
+		{Version{4}, Version{4, 0, 1}, -1},
+	} {
+		if c.want != c.v.Compare(c.w) {
+			t.Fatal(c)
+		}
+	}
+}
+
+type testRegistered struct{}
+
+type testVersion struct {
+	Current bool   `flag:"-c" since:"2.0" until:"3.0"`
+	New     string `flag:"-n" since:"3.0"`
+	Old     bool   `flag:"-o" until:"2.0"`
+}
+
+func (testVersion) VersionProbe() ([]string, string) {
+	return []string{"--version"}, `version (\d+(?:\.\d+)+)`
+}
+
+// testVersionScript prints version 2.5, noting each time it runs.
+var testVersionScript = `echo "$@" >>"$(dirname "$0")/probes"
+echo "$(basename "$0") version 2.5" >&2
+`
@@ -0,0 +1,114 @@
+package shellac
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// Binaries maps commands to the executables that run them, as when GNU find(1)
+// is installed as gfind.  Keys are either a command's name, as in "find", or
+// its type, as in "coreutils.Find", which takes precedence.  Values are either
+// pathnames or names to search for.  Set it before building any commands.
+var Binaries = map[string]string{}
+
+// SearchPath, if non-empty, is searched for executables instead of $PATH.  Set
+// it before building any commands.
+var SearchPath []string
+
+// CheckAvailable returns an error naming every command described by the given
+// interface values, as for Which, that isn't installed or nil if they all are,
+// as when a program starts.
+func CheckAvailable(i ...interface{}) error {
+	var errs []error
+	for _, v := range i {
+		if _, err := Which(v); nil != err {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Which returns the pathname of the executable that runs the command described
+// by the given interface value, which should be a struct or pointer to a
+// struct, considering Binaries and SearchPath.  If it isn't found, the error
+// is an *exec.Error wrapping exec.ErrNotFound.
+func Which(i interface{}) (string, error) {
+	t := reflect.TypeOf(i)
+	if reflect.Ptr == t.Kind() {
+		t = t.Elem()
+	}
+	return which(t)
+}
+
+// binary returns the executable Binaries names for the command described by
+// the struct type or, if there's no override, the command's name.
+func binary(t reflect.Type) string {
+	if name, ok := Binaries[typeName(t)]; ok {
+		return name
+	}
+	if name, ok := Binaries[command(t)]; ok {
+		return name
+	}
+	return command(t)
+}
+
+// lookPath searches SearchPath or, if it's empty, $PATH for the named
+// executable.  Names that contain a slash aren't searched for.
+func lookPath(name string) (string, error) {
+	if 0 == len(SearchPath) || strings.Contains(name, "/") {
+		return exec.LookPath(name)
+	}
+	for _, dirname := range SearchPath {
+		pathname := filepath.Join(dirname, name)
+		fi, err := os.Stat(pathname)
+		if nil == err && fi.Mode().IsRegular() && 0 != fi.Mode()&0111 {
+			return pathname, nil
+		}
+	}
+	return "", &exec.Error{Name: name, Err: exec.ErrNotFound}
+}
+
+// resolve returns the pathname of the executable that runs the command
+// described by the given interface value and its arguments in that
+// executable's Dialect.  If the executable isn't found, its installed version
+// doesn't support a flag that's set, or its Dialect has no equivalent for one,
+// the error says so and the pathname and arguments are the command's name and
+// Args.  Unless the command will run locally, per the given Executor, the
+// executable isn't found, as Binaries names it, or run to find its version or
+// Dialect.
+func resolve(i interface{}, e Executor) (string, []string, error) {
+	t := reflect.TypeOf(i)
+	if reflect.Ptr == t.Kind() {
+		t = t.Elem()
+	}
+	path, err := binary(t), error(nil)
+	if isLocal(e) {
+		if path, err = which(t); nil == err {
+			err = CheckVersion(i)
+		}
+	}
+	var args []string
+	if nil == err {
+		args, err = dialectArgs(i, path, e)
+	}
+	if nil != err {
+		return command(t), Args(i), err
+	}
+	return path, args, nil
+}
+
+// typeName returns the name of the struct type qualified by the last element
+// of its package's path, as in "coreutils.Find".
+func typeName(t reflect.Type) string {
+	return filepath.Base(t.PkgPath()) + "." + t.Name()
+}
+
+// which returns the pathname of the executable that runs the command described
+// by the struct type.
+func which(t reflect.Type) (string, error) {
+	return lookPath(binary(t))
+}
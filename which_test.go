@@ -0,0 +1,99 @@
+package shellac
+
+import (
+	"errors"
+	"github.com/rcrowley/go-shellac/coreutils"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckAvailable(t *testing.T) {
+	if err := CheckAvailable(coreutils.Find{}, &coreutils.Nice{}); nil != err {
+		t.Fatal(err)
+	}
+	err := CheckAvailable(coreutils.Find{}, testDefault{}, testWhich{})
+	if !errors.Is(err, exec.ErrNotFound) {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"testdefault", "testwhich"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Fatal(err)
+		}
+	}
+	if strings.Contains(err.Error(), `"find"`) {
+		t.Fatal(err)
+	}
+}
+
+func TestWhich(t *testing.T) {
+	pathname, err := Which(coreutils.Find{})
+	if nil != err {
+		t.Fatal(err)
+	}
+	if want, _ := exec.LookPath("find"); want != pathname {
+		t.Fatal(pathname)
+	}
+	if _, err := Which(testDefault{}); !errors.Is(err, exec.ErrNotFound) {
+		t.Fatal(err)
+	}
+}
+
+func TestWhichBinaries(t *testing.T) {
+	defer func(binaries map[string]string) { Binaries = binaries }(Binaries)
+	dirname := testScripts(t, "", "gfind", "gnice")
+	Binaries = map[string]string{
+		"find":           filepath.Join(dirname, "gfind"),
+		"nice":           "nice",
+		"coreutils.Nice": filepath.Join(dirname, "gnice"),
+	}
+	cmd := Command(coreutils.Find{Dirnames: []string{"."}})
+	if filepath.Join(dirname, "gfind") != cmd.Path || nil != cmd.Err {
+		t.Fatal(cmd.Path, cmd.Err)
+	}
+	testArgs(t, []string{"find", "."}, cmd.Args)
+	cmd.Wrap(coreutils.Nice{})
+	if filepath.Join(dirname, "gnice") != cmd.Path || nil != cmd.Err {
+		t.Fatal(cmd.Path, cmd.Err)
+	}
+	testArgs(t, []string{"nice", "find", "."}, cmd.Args)
+}
+
+func TestWhichSearchPath(t *testing.T) {
+	defer func(path []string) { SearchPath = path }(SearchPath)
+	dirname := testScripts(t, "", "testwhich")
+	SearchPath = []string{t.TempDir(), dirname}
+	pathname, err := Which(testWhich{})
+	if nil != err {
+		t.Fatal(err)
+	}
+	if filepath.Join(dirname, "testwhich") != pathname {
+		t.Fatal(pathname)
+	}
+	cmd := Command(coreutils.Find{})
+	if !errors.Is(cmd.Err, exec.ErrNotFound) {
+		t.Fatal(cmd.Err)
+	}
+	if err := cmd.Run(); !errors.Is(err, exec.ErrNotFound) {
+		t.Fatal(err)
+	}
+}
+
+type testWhich struct{}
+
+// testScripts creates a temporary directory containing a shell script with the
+// given body for each given name and returns its pathname.
+func testScripts(t *testing.T, body string, names ...string) string {
+	t.Helper()
+	dirname := t.TempDir()
+	for _, name := range names {
+		pathname := filepath.Join(dirname, name)
+		p := []byte("#!/bin/sh\n" + body)
+		if err := os.WriteFile(pathname, p, 0755); nil != err {
+			t.Fatal(err)
+		}
+	}
+	return dirname
+}
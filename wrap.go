@@ -0,0 +1,51 @@
+package shellac
+
+import "reflect"
+
+// Wrap modifies the command to be run via the command described by the given
+// interface value, which should be a struct or pointer to a struct tagged as
+// for Args, like nice(1) or timeout(1).  The wrapper's arguments, including
+// those with pos:"last", come before the command.  Wrapping more than once
+// nests each wrapper around the last.
+func (cmd *Cmd) Wrap(wrapper interface{}) error {
+	prefix, err := cmd.prefix(wrapper)
+	cmd.wrappers = append([][]string{prefix}, cmd.wrappers...)
+	return err
+}
+
+// prefix modifies the command to be run via the command described by the
+// given interface value and returns the command and arguments it prepended.
+// The wrapping command is resolved as by Command but considering the command's
+// Executor or, if the command is from CommandDialect, as by CommandDialect.
+// If it can't be, the command is modified anyway and the error is also kept in
+// cmd.Err, as exec.Command does, so that Start fails.
+func (cmd *Cmd) prefix(i interface{}) ([]string, error) {
+	t := reflect.TypeOf(i)
+	if reflect.Ptr == t.Kind() {
+		t = t.Elem()
+	}
+	name := command(t)
+	var (
+		path string
+		args []string
+		err  error
+	)
+	if nil != cmd.dialect {
+		path = name
+		if args, err = ArgsDialect(i, *cmd.dialect); nil != err {
+			args = Args(i)
+		}
+	} else {
+		path, args, err = resolve(i, cmd.executor())
+	}
+	prefix := append([]string{name}, args...)
+	cmd.Args = append(append([]string{}, prefix...), cmd.Args...)
+	cmd.Secret(secrets(i)...)
+	cmd.values = append(cmd.values, i)
+	if nil != err {
+		cmd.Path, cmd.Err = name, err
+		return prefix, err
+	}
+	cmd.Path = path
+	return prefix, nil
+}
@@ -0,0 +1,96 @@
+package shellac
+
+import (
+	"bytes"
+	"github.com/rcrowley/go-shellac/coreutils"
+	"github.com/rcrowley/go-shellac/utillinux"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestChroot(t *testing.T) {
+	testArgs(t, []string{"--userspec=nobody:nogroup", "/srv/jail"}, Args(
+		coreutils.Chroot{NewRoot: "/srv/jail", UserSpec: "nobody:nogroup"},
+	))
+}
+
+func TestEnv(t *testing.T) {
+	testArgs(t, []string{"-i", "FOO=bar"}, Args(coreutils.Env{
+		IgnoreEnvironment: true,
+		Vars:              []string{"FOO=bar"},
+	}))
+}
+
+func TestIonice(t *testing.T) {
+	testArgs(t, []string{"-c", "3"}, Args(utillinux.Ionice{
+		Class: utillinux.IoniceIdle,
+	}))
+}
+
+func TestNice(t *testing.T) {
+	testArgs(t, []string{}, Args(coreutils.Nice{}))
+	testArgs(t, []string{"-n", "10"}, Args(coreutils.Nice{
+		Adjustment: NewInt(10),
+	}))
+}
+
+func TestNsenter(t *testing.T) {
+	testArgs(t, []string{"-t", "47", "-m"}, Args(utillinux.Nsenter{
+		Mount:  true,
+		Target: 47,
+	}))
+}
+
+func TestTimeout(t *testing.T) {
+	testArgs(t, []string{"-k", "1.5s", "30s"}, Args(coreutils.Timeout{
+		Duration:  coreutils.TimeoutDuration(30 * time.Second),
+		KillAfter: coreutils.TimeoutDuration(1500 * time.Millisecond),
+	}))
+}
+
+func TestWrap(t *testing.T) {
+	cmd := toCmd(exec.Command("sh", "-c", "echo $FOO"))
+	if err := cmd.Wrap(coreutils.Env{
+		IgnoreEnvironment: true,
+		Vars:              []string{"FOO=foo bar"},
+	}); nil != err {
+		t.Fatal(err)
+	}
+	if err := cmd.Wrap(coreutils.Timeout{
+		Duration: coreutils.TimeoutDuration(30 * time.Second),
+	}); nil != err {
+		t.Fatal(err)
+	}
+	if err := cmd.Wrap(coreutils.Nice{Adjustment: NewInt(10)}); nil != err {
+		t.Fatal(err)
+	}
+	testArgs(t, []string{
+		"nice", "-n", "10",
+		"timeout", "30s",
+		"env", "-i", "FOO=foo bar",
+		"sh", "-c", "echo $FOO",
+	}, cmd.Args)
+	var b bytes.Buffer
+	cmd.Logger = NewTextLogger(&b)
+	lines, err := cmd.Lines()
+	if nil != err {
+		t.Fatal(err)
+	}
+	testArgs(t, []string{"foo bar"}, lines)
+	if "nice -n 10 timeout 30s env -i 'FOO=foo bar' sh -c 'echo $FOO'\n" != b.String() {
+		t.Fatal(b.String())
+	}
+}
+
+func TestWrapNotFound(t *testing.T) {
+	t.Setenv("PATH", "")
+	cmd := toCmd(exec.Command("true"))
+	if err := cmd.Wrap(coreutils.Nice{}); nil == err {
+		t.Fatal(cmd)
+	}
+	testArgs(t, []string{"nice", "true"}, cmd.Args)
+	if err := cmd.Run(); nil == err || cmd.Err != err {
+		t.Fatal(err)
+	}
+}